@@ -2,16 +2,34 @@
 package cmd
 
 import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"celify/pkg/printer"
+	"celify/pkg/profiles"
 	"celify/pkg/validate"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
-var target string
+var targets []string
+var oldTargets []string
+var splitLists bool
 var validations string
 var expression string
 var supressObjects bool
+var explain bool
+var include []string
+var exclude []string
+var concurrency int
+var output string
+var outputFile string
+var failOn string
+var params string
+var targetFormat string
+var validationsFormat string
+var profile string
 
 var validateCmd = &cobra.Command{
 	SilenceErrors: true,
@@ -29,11 +47,43 @@ var validateCmd = &cobra.Command{
 	1. Validate a JSON file against a set of rules:
 	   $ celify validate --target tfplan.json --validations validations.yaml
 	
-	2. Validate remote data:
-	   $ celify validate --target "$(curl -s https://example.com/data.json)" --validations validations.yaml
+	2. Validate remote data, or the output of another command, read from stdin:
+	   $ curl -s https://example.com/data.json | celify validate --target - --validations validations.yaml
+	   $ terraform show -json tfplan | celify validate --target - --validations validations.yaml
 	
 	3. Validate a YAML file against a single expression:
 	   $ celify validate --target deployment.yaml --expression "object.spec.replicas > 1"
+
+	4. Print machine readable results:
+	   $ celify validate --target deployment.yaml --validations validations.yaml --output json
+	   $ celify validate --target deployment.yaml --validations validations.yaml --output junit --output-file report.xml
+
+	5. Validate several files, globs and directories in one run:
+	   $ celify validate --target deployment.yaml --target "manifests/**/*.yaml" --target charts/ --exclude "**/testdata/**" --validations validations.yaml
+
+	6. Reuse validations with different parameters, available to expressions as params:
+	   $ celify validate --target deployment.yaml --validations validations.yaml --params team-a.yaml
+
+	7. Run Kubernetes ValidatingAdmissionPolicies, along with their bindings and params, against manifests:
+	   $ celify validate --target "manifests/**/*.yaml" --validations policy.yaml
+
+	8. Validate changes against the previous version of the documents, available to expressions as oldObject:
+	   $ celify validate --old-target before.yaml --target after.yaml --expression "object.spec.selector == oldObject.spec.selector"
+
+	9. Validate every element of a JSON array on its own:
+	   $ celify validate --target deployments.json --split-lists --validations validations.yaml
+
+	10. Fail on warnings as well as errors:
+	   $ celify validate --target deployment.yaml --validations validations.yaml --fail-on warning
+
+	11. Read targets with an explicit format, always taking @ prefixed inputs for files:
+	   $ celify validate --target @manifest.tpl --target-format yaml --validations @validations.yaml
+
+	12. Validate a Terraform plan with the resources and changes it indexes:
+	   $ terraform show -json tfplan | celify validate --target - --profile terraform-plan --expression "changes.deletes.size() == 0"
+
+	13. Validate the dependencies of a Maven pom.xml, every XML element being a list of its occurrences:
+	   $ celify validate --target pom.xml --expression "object.project.dependencies[0].dependency.all(d, d.version[0] != 'LATEST')"
 	
 	`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -41,10 +91,26 @@ var validateCmd = &cobra.Command{
 			return errors.Errorf("You can only provide either a validations file or a single expression")
 		}
 		cmd.SilenceUsage = true
+		opts := validate.Options{
+			SupressObjects:    supressObjects,
+			Explain:           explain,
+			Concurrency:       concurrency,
+			Output:            output,
+			OutputFile:        outputFile,
+			Include:           include,
+			Exclude:           exclude,
+			FailOn:            failOn,
+			Params:            params,
+			OldTargets:        oldTargets,
+			SplitLists:        splitLists,
+			TargetFormat:      targetFormat,
+			ValidationsFormat: validationsFormat,
+			Profile:           profile,
+		}
 		if validations != "" {
-			return validate.Validate(validations, target, supressObjects)
+			return validate.Validate(validations, targets, opts)
 		} else {
-			return validate.ValidateSingleExpression(expression, target, supressObjects)
+			return validate.ValidateSingleExpression(expression, targets, opts)
 		}
 	},
 }
@@ -53,8 +119,21 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 
 	// Here you define the flags for the command
-	validateCmd.Flags().StringVarP(&target, "target", "t", "", "Path to target file or raw string data")
-	validateCmd.Flags().StringVarP(&validations, "validations", "v", "", "Path to the validations YAML file or raw string data - this has to be in correcy yaml format")
+	validateCmd.Flags().StringArrayVarP(&targets, "target", "t", []string{}, "Path to target file, glob, directory, raw string data or - to read from stdin - can be repeated")
+	validateCmd.Flags().BoolVar(&splitLists, "split-lists", false, "validate every element of targets that are lists, like JSON arrays, as a document of its own")
+	validateCmd.Flags().StringArrayVar(&oldTargets, "old-target", []string{}, "Path to the previous version of the targets, as a file, glob, directory or raw string data, available to expressions as oldObject - can be repeated")
+	validateCmd.Flags().StringVarP(&validations, "validations", "v", "", "Path to the validations YAML file, raw string data or - to read from stdin - this has to be in correcy yaml format")
+	validateCmd.Flags().StringVar(&targetFormat, "target-format", "", "format of the targets, one of: "+strings.Join(helpers.Formats, ", ")+" - detected from the file extension or the data when not given")
+	validateCmd.Flags().StringVar(&validationsFormat, "validations-format", "", "format of the validations, one of: "+strings.Join(helpers.Formats, ", ")+" - detected from the file extension or the data when not given")
 	validateCmd.Flags().StringVarP(&expression, "expression", "e", "", "single cel expression to evaluate against the target data")
 	validateCmd.Flags().BoolVarP(&supressObjects, "supress-objects", "s", false, "supress objects from output")
+	validateCmd.Flags().StringVarP(&output, "output", "o", printer.OutputText, "output format, one of: "+strings.Join(printer.Outputs, ", "))
+	validateCmd.Flags().StringVar(&outputFile, "output-file", "", "write results to this file instead of stdout")
+	validateCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 0, "number of workers evaluating targets in parallel - defaults to the number of CPUs")
+	validateCmd.Flags().BoolVar(&explain, "explain", false, "show the value of every sub-expression of failed validations, highlighting the ones that made them fail")
+	validateCmd.Flags().StringVar(&params, "params", "", "Path to the params YAML or JSON file or raw string data, available to expressions as params")
+	validateCmd.Flags().StringVar(&profile, "profile", "", "profile adding variables and helpers tailored to the targets, one of: "+strings.Join(profiles.Names(), ", "))
+	validateCmd.Flags().StringVar(&failOn, "fail-on", models.SeverityError, "least severe failed validation making the command fail, one of: "+strings.Join(models.Severities, ", ")+", "+validate.FailOnNone)
+	validateCmd.Flags().StringArrayVar(&include, "include", []string{}, "pattern of files to validate when walking target directories - defaults to the files of every known format: "+strings.Join(validate.DefaultInclude, ", "))
+	validateCmd.Flags().StringArrayVar(&exclude, "exclude", []string{}, "pattern of files or directories to skip when walking target directories or expanding globs")
 }
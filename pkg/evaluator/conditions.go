@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+type compiledCondition struct {
+	condition models.MatchCondition
+	program   cel.Program
+}
+
+// compileConditions compiles match conditions, leaving out the ones that fail to compile
+func (ev *Evaluator) compileConditions(conditions []models.MatchCondition) ([]compiledCondition, []error) {
+	compiled := []compiledCondition{}
+	errs := []error{}
+	for _, condition := range conditions {
+		expression, err := ev.compileExpression(condition.Expression, cel.BoolType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("match condition '%s': %v", conditionLabel(condition), err))
+			continue
+		}
+		compiled = append(compiled, compiledCondition{condition: condition, program: expression.program})
+	}
+	return compiled, errs
+}
+
+// matchConditions evaluates the match conditions of a rule in order, returning the first one not met, if any
+func (ev *Evaluator) matchConditions(compiled CompiledRule) (*models.MatchCondition, error) {
+	for _, condition := range compiled.matchConditions {
+		matched, err := ev.executeProgram(condition.program, BoolType)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating match condition '%s': %v", conditionLabel(condition.condition), err)
+		}
+		if !matched.(bool) {
+			return &condition.condition, nil
+		}
+	}
+	return nil, nil
+}
+
+func conditionLabel(condition models.MatchCondition) string {
+	if condition.Name != "" {
+		return condition.Name
+	}
+	return condition.Expression
+}
+
+func skippedResult(compiled CompiledRule, condition models.MatchCondition) models.EvaluationResult {
+	return models.EvaluationResult{
+		Rule:       compiled.Rule,
+		Expression: compiled.Rule.Expression,
+		Skipped:    true,
+		Message:    fmt.Sprintf("match condition '%s' not met", conditionLabel(condition)),
+	}
+}
@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestMatchConditions(t *testing.T) {
+	validations := models.ValidationConfig{
+		MatchConditions: []models.MatchCondition{
+			{Name: "has-kind", Expression: "has(object.kind)"},
+		},
+		Validations: []models.ValidationRule{
+			{
+				MatchConditions: []models.MatchCondition{
+					{Name: "is-deployment", Expression: "object.kind == 'Deployment'"},
+				},
+				Expression: "object.spec.replicas > 1",
+			},
+			{
+				MatchConditions: []models.MatchCondition{
+					{Expression: "object.kind == 'ConfigMap'"},
+				},
+				Expression: "has(object.data)",
+			},
+		},
+	}
+	testCases := []struct {
+		name     string
+		object   map[string]interface{}
+		skipped  []string
+		failures []bool
+	}{
+		{
+			name:     "deployment",
+			object:   map[string]interface{}{"kind": "Deployment", "spec": map[string]interface{}{"replicas": 1}},
+			skipped:  []string{"", "match condition 'object.kind == 'ConfigMap'' not met"},
+			failures: []bool{true, false},
+		},
+		{
+			name:     "config map",
+			object:   map[string]interface{}{"kind": "ConfigMap", "data": map[string]interface{}{}},
+			skipped:  []string{"match condition 'is-deployment' not met", ""},
+			failures: []bool{false, false},
+		},
+		{
+			name:     "no kind",
+			object:   map[string]interface{}{"spec": map[string]interface{}{}},
+			skipped:  []string{"match condition 'has-kind' not met", "match condition 'has-kind' not met"},
+			failures: []bool{false, false},
+		},
+	}
+	eval, err := NewEvaluator(nil)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	ruleSet, err := eval.Compile(validations)
+	if err != nil {
+		t.Fatalf("Error compiling validations: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := &models.TargetData{Data: map[string]interface{}{"object": tc.object}, Format: "yaml"}
+			results := eval.ForTarget(target).EvaluateRuleSet(ruleSet)
+			for i, result := range results {
+				if result.Skipped != (tc.skipped[i] != "") {
+					t.Errorf("Rule %d: expected skipped to be %v, got %v", i+1, tc.skipped[i] != "", result.Skipped)
+				}
+				if result.Skipped && result.Message != tc.skipped[i] {
+					t.Errorf("Rule %d: expected message '%s', got '%s'", i+1, tc.skipped[i], result.Message)
+				}
+				if (result.ValidationError != nil) != tc.failures[i] {
+					t.Errorf("Rule %d: expected failure to be %v, got %v", i+1, tc.failures[i], result.ValidationError)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchConditionErrors(t *testing.T) {
+	eval, err := NewEvaluator(&models.TargetData{Data: map[string]interface{}{"object": map[string]interface{}{}}})
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	_, err = eval.Compile(models.ValidationConfig{
+		MatchConditions: []models.MatchCondition{{Name: "not-bool", Expression: "size(object)"}},
+		Validations:     []models.ValidationRule{{Expression: "true"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "match condition 'not-bool'") {
+		t.Errorf("Expected match condition compile error, got %v", err)
+	}
+	result := eval.EvaluateRule(models.ValidationRule{
+		MatchConditions: []models.MatchCondition{{Expression: "object.kind == 'Deployment'"}},
+		Expression:      "true",
+	})
+	if result.ValidationError == nil || !strings.Contains(result.ValidationError.Error(), "error evaluating match condition") {
+		t.Errorf("Expected match condition evaluation error, got %v", result.ValidationError)
+	}
+}
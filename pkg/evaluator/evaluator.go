@@ -1,13 +1,18 @@
 package evaluator
 
 import (
-	"celify/pkg/helpers"
 	"celify/pkg/models"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/interpreter"
 	"github.com/pkg/errors"
 )
 
@@ -20,14 +25,51 @@ var (
 
 type Evaluator struct {
 	TargetData *models.TargetData
-	env        *cel.Env
+	// Explain adds the evaluation trace of every failed validation to its result
+	Explain bool
+	// Params is the parameter object shared by every target, available to expressions as params
+	Params         interface{}
+	env            *cel.Env
+	programs       *programCache
+	variables      []compiledVariable
+	profile        *Profile
+	vars           interpreter.Activation
+	activationOnce sync.Once
+}
+
+// programCache shares compiled programs between an evaluator and the ones derived from it
+type programCache struct {
+	mu       sync.RWMutex
+	programs map[string]compiledProgram
+}
+
+type compiledProgram struct {
+	program cel.Program
+	ast     *cel.Ast
+}
+
+func (c *programCache) get(expression string) (compiledProgram, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	compiled, ok := c.programs[expression]
+	return compiled, ok
+}
+
+func (c *programCache) put(expression string, compiled compiledProgram) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.programs[expression] = compiled
 }
 
 func NewEvaluator(targetInput *models.TargetData) (*Evaluator, error) {
 	env, err := cel.NewEnv(
 		cel.Declarations(
-			decls.NewVar("object", decls.NewMapType(decls.String, decls.Dyn)),
+			decls.NewVar("object", decls.Dyn),
+			decls.NewVar("oldObject", decls.Dyn),
+			decls.NewVar("params", decls.Dyn),
 		),
+		// macro calls are kept so failed all()/exists() macros can be evaluated element by element
+		cel.EnableMacroCallTracking(),
 	)
 	if err != nil {
 		return nil, err
@@ -35,56 +77,172 @@ func NewEvaluator(targetInput *models.TargetData) (*Evaluator, error) {
 	return &Evaluator{
 		TargetData: targetInput,
 		env:        env,
+		programs:   &programCache{programs: map[string]compiledProgram{}},
 	}, nil
 }
 
+// ForTarget returns an evaluator for the given target sharing this evaluator's environment and compiled programs
+func (ev *Evaluator) ForTarget(targetInput *models.TargetData) *Evaluator {
+	return &Evaluator{
+		TargetData: targetInput,
+		Explain:    ev.Explain,
+		Params:     ev.Params,
+		env:        ev.env,
+		programs:   ev.programs,
+		variables:  ev.variables,
+		profile:    ev.profile,
+	}
+}
+
 func (ev *Evaluator) executeEvaluation(expression string, expectedReturnType reflect.Type) (interface{}, error) {
 	pgr, err := ev.getProgram(expression)
 	if err != nil {
 		return nil, fmt.Errorf("error getting program: %v", err)
 	}
-	out, _, err := pgr.Eval(ev.TargetData.Data)
+	return ev.executeProgram(pgr, expectedReturnType)
+}
+
+func (ev *Evaluator) executeProgram(pgr cel.Program, expectedReturnType reflect.Type) (interface{}, error) {
+	out, _, err := pgr.Eval(ev.activation())
 	if err != nil {
 		return nil, fmt.Errorf("error evaluating expression: %v", err)
 	}
+	if expectedReturnType == AnyType {
+		return nativeValue(out), nil
+	}
 	return out.ConvertToNative(expectedReturnType)
 }
 
+// nativeValue converts a CEL value to plain Go values, including the CEL values held by lists and maps
+func nativeValue(val ref.Val) interface{} {
+	switch v := val.(type) {
+	case traits.Lister:
+		list := []interface{}{}
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			list = append(list, nativeValue(it.Next()))
+		}
+		return list
+	case traits.Mapper:
+		m := map[string]interface{}{}
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			key := it.Next()
+			m[fmt.Sprint(key.Value())] = nativeValue(v.Get(key))
+		}
+		return m
+	case types.Null:
+		return nil
+	}
+	return val.Value()
+}
+
+// EvaluateRule compiles and evaluates a single rule, reporting compile errors as a failed validation
 func (ev *Evaluator) EvaluateRule(rule models.ValidationRule) models.EvaluationResult {
-	result, err := ev.executeEvaluation(rule.Expression, BoolType)
+	compiled, errs := ev.compileRule(rule, nil)
+	if !compiled.evaluable(nil) {
+		return ev.handleFailedRule(compiled, fmt.Errorf("error getting program: %v", errs[0]), nil)
+	}
+	return ev.EvaluateCompiledRule(compiled)
+}
+
+// EvaluateCompiledRule evaluates a rule, skipping it when its match conditions aren't met
+func (ev *Evaluator) EvaluateCompiledRule(compiled CompiledRule) models.EvaluationResult {
+	unmet, err := ev.matchConditions(compiled)
+	if err != nil {
+		return ev.handleFailedRule(compiled, err, nil)
+	}
+	if unmet != nil {
+		return skippedResult(compiled, *unmet)
+	}
+	result, err := ev.executeProgram(compiled.program, BoolType)
 	if err != nil || !result.(bool) {
-		return ev.handleFailedRule(rule, err, result)
+		return ev.handleFailedRule(compiled, err, result)
 	}
 
 	return models.EvaluationResult{
-		Expression: rule.Expression,
+		Rule:       compiled.Rule,
+		Expression: compiled.Rule.Expression,
 	}
 }
 
-func (ev *Evaluator) Evaluate(validations models.ValidationConfig) []models.EvaluationResult {
+// Evaluate compiles the validations and evaluates them against the target
+func (ev *Evaluator) Evaluate(validations models.ValidationConfig) ([]models.EvaluationResult, error) {
+	ruleSet, err := ev.Compile(validations)
+	if err != nil {
+		return nil, err
+	}
+	return ev.EvaluateRuleSet(ruleSet), nil
+}
+
+func (ev *Evaluator) EvaluateRuleSet(ruleSet *RuleSet) []models.EvaluationResult {
 	var evalResults []models.EvaluationResult
-	for _, validation := range validations.Validations {
-		evalResults = append(evalResults, ev.EvaluateRule(validation))
+	for _, compiled := range ruleSet.Rules {
+		evalResults = append(evalResults, ev.EvaluateCompiledRule(compiled))
 	}
 	return evalResults
 }
 
 func (ev *Evaluator) getProgram(expression string) (cel.Program, error) {
-	ast, issues := ev.env.Compile(expression)
+	compiled, err := ev.compileExpression(expression, nil)
+	return compiled.program, err
+}
+
+// getVariableProgram compiles an expression referencing an extra variable, like a macro predicate
+func (ev *Evaluator) getVariableProgram(variable, expression string) (cel.Program, error) {
+	key := fmt.Sprintf("%s -> %s", variable, expression)
+	if compiled, ok := ev.programs.get(key); ok {
+		return compiled.program, nil
+	}
+	env, err := ev.env.Extend(cel.Variable(variable, cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
 	if issues != nil && issues.Err() != nil {
 		return nil, errors.Errorf("Failed to compile expression '%s': %v", expression, issues.Err())
 	}
-	pgr, err := ev.env.Program(ast)
+	pgr, err := env.Program(ast)
 	if err != nil {
 		return nil, errors.Errorf("Failed to generate program for expression '%s': %v", expression, err)
 	}
+	ev.programs.put(key, compiledProgram{program: pgr, ast: ast})
 	return pgr, nil
 }
 
-func (ev *Evaluator) handleFailedRule(rule models.ValidationRule, executionError error, result interface{}) models.EvaluationResult {
-	objectsExpr := helpers.ExtractObjects(rule.Expression)
+func (ev *Evaluator) compileExpression(expression string, expectedType *cel.Type) (compiledProgram, error) {
+	compiled, ok := ev.programs.get(expression)
+	if !ok {
+		ast, issues := ev.env.Compile(expression)
+		if issues != nil && issues.Err() != nil {
+			return compiledProgram{}, errors.Errorf("Failed to compile expression '%s': %v", expression, issues.Err())
+		}
+		pgr, err := ev.env.Program(ast)
+		if err != nil {
+			return compiledProgram{}, errors.Errorf("Failed to generate program for expression '%s': %v", expression, err)
+		}
+		compiled = compiledProgram{program: pgr, ast: ast}
+		ev.programs.put(expression, compiled)
+	}
+	outputType := compiled.ast.OutputType()
+	if expectedType != nil && !outputType.IsExactType(cel.DynType) && !expectedType.IsAssignableType(outputType) {
+		return compiledProgram{}, errors.Errorf("Expression '%s' must return %s, got %s", expression, expectedType, outputType)
+	}
+	return compiled, nil
+}
+
+func (ev *Evaluator) handleFailedRule(compiled CompiledRule, executionError error, result interface{}) models.EvaluationResult {
+	rule := compiled.Rule
+	objectsExpr := extractObjects(compiled.ast, ev.profileRoots()...)
+	// elements failing all()/exists() macros are reported instead of their whole range
+	elementFailures := ev.elementFailures(compiled.ast)
 	objects := []models.EvaluatedObject{}
 	for _, objExpr := range objectsExpr {
+		if elements, ok := elementFailures[objExpr]; ok {
+			objects = append(objects, elements...)
+			continue
+		}
+		if reportsFailedRange(objExpr, elementFailures) {
+			continue
+		}
 		evaluatedObj, err := ev.executeEvaluation(objExpr, AnyType)
 		if err != nil {
 			evaluatedObj = fmt.Sprintf("unable to evaluate object: %v", err)
@@ -92,27 +250,51 @@ func (ev *Evaluator) handleFailedRule(rule models.ValidationRule, executionError
 		objects = append(objects, models.EvaluatedObject{
 			Expression: objExpr,
 			Object:     evaluatedObj,
+			Position:   ev.objectPosition(objExpr),
 		})
 	}
 
-	var validationError error
+	message := "validation failed"
 	if rule.MessageExpression != "" {
-		msgExpr, err := ev.executeEvaluation(rule.MessageExpression, StringType)
+		var msgExpr interface{}
+		var err error
+		if compiled.messageProgram != nil {
+			msgExpr, err = ev.executeProgram(compiled.messageProgram, StringType)
+		} else {
+			msgExpr, err = ev.executeEvaluation(rule.MessageExpression, StringType)
+		}
 		if err != nil {
 			msgExpr = fmt.Sprintf("unable to evaluate message expression: %v", err)
 		}
-		validationError = fmt.Errorf("message: %s", msgExpr.(string))
-	} else {
-		validationError = errors.New("message: validation failed")
+		message = msgExpr.(string)
 	}
+	validationError := fmt.Errorf("message: %s", message)
 
 	if executionError != nil {
 		validationError = fmt.Errorf("%w | %w", executionError, validationError)
 	}
 
+	var explanation *models.ExplainNode
+	if ev.Explain && compiled.program != nil {
+		explanation = ev.explain(compiled)
+	}
+
 	return models.EvaluationResult{
+		Rule:             rule,
 		Expression:       rule.Expression,
+		Message:          message,
 		ValidationError:  validationError,
 		EvaluatedObjects: objects,
+		Explanation:      explanation,
+	}
+}
+
+// reportsFailedRange tells whether an object maps over a range already reported element by element
+func reportsFailedRange(objExpr string, elementFailures map[string][]models.EvaluatedObject) bool {
+	for iterRange := range elementFailures {
+		if strings.HasPrefix(objExpr, iterRange+".map(") {
+			return true
+		}
 	}
+	return false
 }
@@ -30,6 +30,7 @@ var evalTests = []struct {
 		},
 		expected: []models.EvaluationResult{
 			{
+				Rule:       models.ValidationRule{Expression: "object.foo == 'bar'"},
 				Expression: "object.foo == 'bar'",
 			},
 		},
@@ -53,6 +54,10 @@ var evalTests = []struct {
 		},
 		expected: []models.EvaluationResult{
 			{
+				Rule: models.ValidationRule{
+					Expression:        "object.foo == 'baz'",
+					MessageExpression: "'foo should be baz but was ' + object.foo",
+				},
 				Expression: "object.foo == 'baz'",
 				EvaluatedObjects: []models.EvaluatedObject{
 					{
@@ -60,6 +65,7 @@ var evalTests = []struct {
 						Object:     "bar",
 					},
 				},
+				Message:         "foo should be baz but was bar",
 				ValidationError: fmt.Errorf("message: foo should be baz but was bar"),
 			},
 		},
@@ -72,7 +78,7 @@ func TestEvaluate(t *testing.T) {
 		if err != nil {
 			t.Errorf("Error creating evaluator: %v", err)
 		}
-		result := eval.Evaluate(tc.validations)
+		result, err := eval.Evaluate(tc.validations)
 		if err != nil {
 			t.Errorf("Error evaluating expression: %v", err)
 		}
@@ -83,6 +89,29 @@ func TestEvaluate(t *testing.T) {
 	}
 }
 
+func TestEvaluateCompileErrors(t *testing.T) {
+	validations := models.ValidationConfig{
+		Validations: []models.ValidationRule{
+			{Expression: "object.foo == 'bar'"},
+			{Expression: "object.foo +"},
+		},
+	}
+	target := &models.TargetData{Data: map[string]interface{}{"object": map[string]interface{}{"foo": "bar"}}}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	_, evaluateErr := eval.Evaluate(validations)
+	eval, err = NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	_, compileErr := eval.Compile(validations)
+	if evaluateErr == nil || compileErr == nil || evaluateErr.Error() != compileErr.Error() {
+		t.Errorf("Expected the compile error '%v', got '%v'", compileErr, evaluateErr)
+	}
+}
+
 func TestEvaluateSingleExpression(t *testing.T) {
 	for _, test := range evalTests {
 		eval, err := NewEvaluator(test.targetData)
@@ -169,3 +198,34 @@ func TestExecuteEvaluation(t *testing.T) {
 		}
 	}
 }
+
+func TestParams(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{
+			"object": map[string]interface{}{"replicas": 2},
+		},
+		Format: "yaml",
+	}
+	testCases := []struct {
+		params     interface{}
+		expression string
+		passed     bool
+	}{
+		{params: map[string]interface{}{"minReplicas": 2}, expression: "object.replicas >= params.minReplicas", passed: true},
+		{params: map[string]interface{}{"minReplicas": 3}, expression: "object.replicas >= params.minReplicas", passed: false},
+		{params: nil, expression: "params == null", passed: true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s with %v", tc.expression, tc.params), func(t *testing.T) {
+			eval, err := NewEvaluator(nil)
+			if err != nil {
+				t.Fatalf("Error creating evaluator: %v", err)
+			}
+			eval.Params = tc.params
+			result := eval.ForTarget(target).EvaluateRule(models.ValidationRule{Expression: tc.expression})
+			if (result.ValidationError == nil) != tc.passed {
+				t.Errorf("Expected passed to be %v, got %v", tc.passed, result.ValidationError)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter"
+)
+
+var relationalOperators = map[string]bool{
+	operators.Equals:        true,
+	operators.NotEquals:     true,
+	operators.Less:          true,
+	operators.LessEquals:    true,
+	operators.Greater:       true,
+	operators.GreaterEquals: true,
+	operators.In:            true,
+}
+
+// explain evaluates the rule again without short-circuits and builds the tree of its clauses
+func (ev *Evaluator) explain(compiled CompiledRule) *models.ExplainNode {
+	expr, info, err := nativeAst(compiled.ast)
+	if err != nil {
+		return nil
+	}
+	pgr, err := ev.getExplainProgram(compiled.Rule.Expression, compiled.ast)
+	if err != nil {
+		return nil
+	}
+	_, details, _ := pgr.Eval(ev.activation())
+	if details == nil || details.State() == nil {
+		return nil
+	}
+	node := explainNode(expr, info, details.State())
+	markCulprits(&node, expr)
+	return &node
+}
+
+func (ev *Evaluator) getExplainProgram(expression string, ast *cel.Ast) (cel.Program, error) {
+	key := fmt.Sprintf("explain -> %s", expression)
+	if compiled, ok := ev.programs.get(key); ok {
+		return compiled.program, nil
+	}
+	pgr, err := ev.env.Program(ast, cel.EvalOptions(cel.OptExhaustiveEval))
+	if err != nil {
+		return nil, err
+	}
+	ev.programs.put(key, compiledProgram{program: pgr, ast: ast})
+	return pgr, nil
+}
+
+func explainNode(e celast.Expr, info *celast.SourceInfo, state interpreter.EvalState) models.ExplainNode {
+	expression, err := unparse(e, info)
+	if err != nil {
+		expression = fmt.Sprintf("<expression %d>", e.ID())
+	}
+	node := models.ExplainNode{Expression: expression}
+	if val, ok := state.Value(e.ID()); ok && val != nil {
+		node.Evaluated = true
+		node.Value = explainValue(val)
+	}
+	if e.Kind() != celast.CallKind {
+		return node
+	}
+	call := e.AsCall()
+	switch fn := call.FunctionName(); {
+	case fn == operators.LogicalAnd || fn == operators.LogicalOr || fn == operators.LogicalNot || fn == operators.Conditional:
+		for _, arg := range call.Args() {
+			node.Children = append(node.Children, explainNode(arg, info, state))
+		}
+	case relationalOperators[fn]:
+		for _, arg := range call.Args() {
+			if arg.Kind() == celast.LiteralKind {
+				continue
+			}
+			operand := explainNode(arg, info, state)
+			operand.Children = nil
+			node.Children = append(node.Children, operand)
+		}
+	}
+	return node
+}
+
+func explainValue(val ref.Val) interface{} {
+	if types.IsError(val) || types.IsUnknown(val) {
+		return fmt.Sprint(val)
+	}
+	return val.Value()
+}
+
+// markCulprits flags the clauses that made the node fail
+func markCulprits(node *models.ExplainNode, e celast.Expr) {
+	if result, ok := node.Value.(bool); ok && result {
+		return
+	}
+	node.Culprit = true
+	if e.Kind() != celast.CallKind {
+		return
+	}
+	call := e.AsCall()
+	args := call.Args()
+	switch call.FunctionName() {
+	case operators.LogicalAnd, operators.LogicalOr:
+		for i := range args {
+			markCulprits(&node.Children[i], args[i])
+		}
+	case operators.LogicalNot:
+		node.Children[0].Culprit = true
+	case operators.Conditional:
+		if condition, ok := node.Children[0].Value.(bool); ok {
+			if condition {
+				markCulprits(&node.Children[1], args[1])
+			} else {
+				markCulprits(&node.Children[2], args[2])
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{
+			"object": map[string]interface{}{
+				"replicas": 1,
+				"image":    "nginx:latest",
+			},
+		},
+		Format: "yaml",
+	}
+	testCases := []struct {
+		expression string
+		culprits   []string
+	}{
+		{
+			expression: "object.replicas > 2 && object.image.startsWith('nginx')",
+			culprits:   []string{"object.replicas > 2 && object.image.startsWith(\"nginx\")", "object.replicas > 2"},
+		},
+		{
+			expression: "object.replicas > 2 || !object.image.endsWith(':latest')",
+			culprits: []string{
+				"object.replicas > 2 || !object.image.endsWith(\":latest\")",
+				"object.replicas > 2",
+				"!object.image.endsWith(\":latest\")",
+				"object.image.endsWith(\":latest\")",
+			},
+		},
+		{
+			expression: "object.replicas == 1 ? object.image == 'busybox' : true",
+			culprits:   []string{"(object.replicas == 1) ? (object.image == \"busybox\") : true", "object.image == \"busybox\""},
+		},
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	eval.Explain = true
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			result := eval.EvaluateRule(models.ValidationRule{Expression: tc.expression})
+			if result.Explanation == nil {
+				t.Fatalf("Expected an explanation")
+			}
+			culprits := []string{}
+			var walk func(node models.ExplainNode)
+			walk = func(node models.ExplainNode) {
+				if node.Culprit {
+					culprits = append(culprits, node.Expression)
+				}
+				for _, child := range node.Children {
+					walk(child)
+				}
+			}
+			walk(*result.Explanation)
+			if len(culprits) != len(tc.culprits) {
+				t.Fatalf("Expected culprits %v, got %v", tc.culprits, culprits)
+			}
+			for i := range culprits {
+				if culprits[i] != tc.culprits[i] {
+					t.Errorf("Expected culprits %v, got %v", tc.culprits, culprits)
+				}
+			}
+		})
+	}
+}
+
+func TestExplainDisabled(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{"object": map[string]interface{}{"replicas": 1}},
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	result := eval.EvaluateRule(models.ValidationRule{Expression: "object.replicas > 2"})
+	if result.Explanation != nil {
+		t.Errorf("Expected no explanation, got %v", result.Explanation)
+	}
+}
@@ -0,0 +1,232 @@
+package evaluator
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/interpreter"
+)
+
+// quantifier is an all(), exists() or exists_one() macro over a range that doesn't depend on other comprehensions
+type quantifier struct {
+	macro     string
+	macroExpr string
+	iterRange string
+	iterVar   string
+	predicate string
+}
+
+// elementFailures returns the elements that made the quantifier macros of a failed rule fail, keyed by their range
+func (ev *Evaluator) elementFailures(ast *cel.Ast) map[string][]models.EvaluatedObject {
+	failures := map[string][]models.EvaluatedObject{}
+	for _, q := range findQuantifiers(ast) {
+		result, err := ev.executeEvaluation(q.macroExpr, BoolType)
+		if err != nil || result.(bool) {
+			continue
+		}
+		elements, err := ev.quantifierElements(q)
+		if err != nil {
+			continue
+		}
+		if reported, ok := failures[q.iterRange]; ok {
+			elements = ev.mergeElements(q.iterRange, reported, elements)
+		}
+		failures[q.iterRange] = elements
+	}
+	return failures
+}
+
+// mergeElements merges the elements failing several quantifiers over the same range, in range order
+func (ev *Evaluator) mergeElements(iterRange string, reported, elements []models.EvaluatedObject) []models.EvaluatedObject {
+	failing := map[string]models.EvaluatedObject{}
+	for _, object := range append(append([]models.EvaluatedObject{}, reported...), elements...) {
+		if _, ok := failing[object.Expression]; !ok {
+			failing[object.Expression] = object
+		}
+	}
+	rangeElements, err := ev.rangeElements(iterRange)
+	if err != nil {
+		return reported
+	}
+	merged := []models.EvaluatedObject{}
+	for _, element := range rangeElements {
+		if object, ok := failing[element.expression]; ok {
+			merged = append(merged, object)
+		}
+	}
+	return merged
+}
+
+func findQuantifiers(ast *cel.Ast) []quantifier {
+	expr, info, err := nativeAst(ast)
+	if err != nil {
+		return nil
+	}
+	quantifiers := []quantifier{}
+	var walk func(e celast.Expr)
+	walk = func(e celast.Expr) {
+		switch e.Kind() {
+		case celast.ComprehensionKind:
+			// nested comprehensions may depend on the outer iteration variable, so only top level ones are inspected
+			if q, ok := toQuantifier(e, info); ok {
+				quantifiers = append(quantifiers, q)
+			}
+			walk(e.AsComprehension().IterRange())
+		case celast.SelectKind:
+			walk(e.AsSelect().Operand())
+		case celast.CallKind:
+			call := e.AsCall()
+			if call.IsMemberFunction() {
+				walk(call.Target())
+			}
+			for _, arg := range call.Args() {
+				walk(arg)
+			}
+		case celast.ListKind:
+			for _, elem := range e.AsList().Elements() {
+				walk(elem)
+			}
+		}
+	}
+	walk(expr)
+	return quantifiers
+}
+
+func toQuantifier(e celast.Expr, info *celast.SourceInfo) (quantifier, bool) {
+	macroCall, ok := info.GetMacroCall(e.ID())
+	if !ok || macroCall.Kind() != celast.CallKind {
+		return quantifier{}, false
+	}
+	comp := e.AsComprehension()
+	macro := macroCall.AsCall().FunctionName()
+	step := comp.LoopStep()
+	var predicate celast.Expr
+	switch macro {
+	case operators.All, operators.Exists:
+		// all() steps with __result__ && predicate, exists() with __result__ || predicate
+		if step.Kind() != celast.CallKind || len(step.AsCall().Args()) != 2 {
+			return quantifier{}, false
+		}
+		predicate = step.AsCall().Args()[1]
+	case operators.ExistsOne:
+		// exists_one() steps with predicate ? __result__ + 1 : __result__
+		if step.Kind() != celast.CallKind || step.AsCall().FunctionName() != operators.Conditional {
+			return quantifier{}, false
+		}
+		predicate = step.AsCall().Args()[0]
+	default:
+		return quantifier{}, false
+	}
+	macroExpr, err := unparse(e, info)
+	if err != nil {
+		return quantifier{}, false
+	}
+	iterRange, err := unparse(comp.IterRange(), info)
+	if err != nil {
+		return quantifier{}, false
+	}
+	predicateExpr, err := unparse(predicate, info)
+	if err != nil {
+		return quantifier{}, false
+	}
+	return quantifier{
+		macro:     macro,
+		macroExpr: macroExpr,
+		iterRange: iterRange,
+		iterVar:   comp.IterVar(),
+		predicate: predicateExpr,
+	}, true
+}
+
+type rangeElement struct {
+	expression string
+	key        interface{}
+	value      interface{}
+}
+
+// quantifierElements returns the elements of the range responsible for the macro failing
+func (ev *Evaluator) quantifierElements(q quantifier) ([]models.EvaluatedObject, error) {
+	elements, err := ev.rangeElements(q.iterRange)
+	if err != nil {
+		return nil, err
+	}
+	pgr, err := ev.getVariableProgram(q.iterVar, q.predicate)
+	if err != nil {
+		return nil, err
+	}
+	matching := []models.EvaluatedObject{}
+	failing := []models.EvaluatedObject{}
+	for _, element := range elements {
+		vars, err := interpreter.NewActivation(map[string]interface{}{q.iterVar: element.key})
+		if err != nil {
+			return nil, err
+		}
+		object := models.EvaluatedObject{
+			Expression: element.expression,
+			Object:     element.value,
+			Name:       helpers.LookupString(element.value, "name"),
+			Position:   ev.objectPosition(element.expression),
+		}
+		out, _, err := pgr.Eval(interpreter.NewHierarchicalActivation(ev.activation(), vars))
+		if err != nil {
+			object.Object = fmt.Sprintf("unable to evaluate predicate: %v", err)
+			failing = append(failing, object)
+			continue
+		}
+		if matched, ok := out.Value().(bool); ok && matched {
+			matching = append(matching, object)
+		} else {
+			failing = append(failing, object)
+		}
+	}
+	switch q.macro {
+	case operators.All:
+		return failing, nil
+	case operators.ExistsOne:
+		if len(matching) > 1 {
+			return matching, nil
+		}
+	}
+	return append(matching, failing...), nil
+}
+
+// rangeElements evaluates a macro range into its elements, map keys being sorted
+func (ev *Evaluator) rangeElements(iterRange string) ([]rangeElement, error) {
+	value, err := ev.executeEvaluation(iterRange, AnyType)
+	if err != nil {
+		return nil, err
+	}
+	rv := reflect.ValueOf(value)
+	elements := []rangeElement{}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elements = append(elements, rangeElement{
+				expression: fmt.Sprintf("%s[%d]", iterRange, i),
+				key:        rv.Index(i).Interface(),
+				value:      rv.Index(i).Interface(),
+			})
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			elements = append(elements, rangeElement{
+				expression: fmt.Sprintf("%s[%q]", iterRange, fmt.Sprint(key.Interface())),
+				key:        key.Interface(),
+				value:      rv.MapIndex(key).Interface(),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unable to iterate over %T", value)
+	}
+	return elements, nil
+}
@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"reflect"
+	"testing"
+)
+
+func TestElementFailures(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{
+			"object": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "app", "image": "team-a/app"},
+					map[string]interface{}{"name": "sidecar", "image": "envoy"},
+					map[string]interface{}{"name": "init", "image": "team-a/init"},
+				},
+				"labels": map[string]interface{}{"team": "a", "env": "dev"},
+			},
+		},
+		Format: "yaml",
+	}
+	testCases := []struct {
+		expression string
+		expected   []models.EvaluatedObject
+	}{
+		{
+			expression: "object.containers.all(c, c.image.startsWith('team-a'))",
+			expected: []models.EvaluatedObject{
+				{
+					Expression: "object.containers[1]",
+					Object:     map[string]interface{}{"name": "sidecar", "image": "envoy"},
+					Name:       "sidecar",
+				},
+			},
+		},
+		{
+			expression: "object.containers.exists_one(c, c.image.startsWith('team-a'))",
+			expected: []models.EvaluatedObject{
+				{
+					Expression: "object.containers[0]",
+					Object:     map[string]interface{}{"name": "app", "image": "team-a/app"},
+					Name:       "app",
+				},
+				{
+					Expression: "object.containers[2]",
+					Object:     map[string]interface{}{"name": "init", "image": "team-a/init"},
+					Name:       "init",
+				},
+			},
+		},
+		{
+			expression: "object.labels.exists(k, k == 'owner')",
+			expected: []models.EvaluatedObject{
+				{Expression: `object.labels["env"]`, Object: "dev"},
+				{Expression: `object.labels["team"]`, Object: "a"},
+			},
+		},
+		{
+			expression: "object.containers.all(c, c.name != 'app') && object.containers.all(c, c.image.startsWith('team-a'))",
+			expected: []models.EvaluatedObject{
+				{
+					Expression: "object.containers[0]",
+					Object:     map[string]interface{}{"name": "app", "image": "team-a/app"},
+					Name:       "app",
+				},
+				{
+					Expression: "object.containers[1]",
+					Object:     map[string]interface{}{"name": "sidecar", "image": "envoy"},
+					Name:       "sidecar",
+				},
+			},
+		},
+		{
+			expression: "object.containers.all(c, c.name != 'init') && object.containers.exists(c, c.name == 'init')",
+			expected: []models.EvaluatedObject{
+				{
+					Expression: "object.containers[2]",
+					Object:     map[string]interface{}{"name": "init", "image": "team-a/init"},
+					Name:       "init",
+				},
+			},
+		},
+		{
+			expression: "object.containers.all(c, c.name != '') && object.labels.team == 'b'",
+			expected:   nil,
+		},
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			compiled, err := eval.compileExpression(tc.expression, nil)
+			if err != nil {
+				t.Fatalf("Error compiling expression: %v", err)
+			}
+			failures := eval.elementFailures(compiled.ast)
+			var actual []models.EvaluatedObject
+			for _, elements := range failures {
+				actual = append(actual, elements...)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEvaluateReportsFailingElements(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{
+			"object": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "app", "memory": "1Gi"},
+					map[string]interface{}{"name": "sidecar"},
+				},
+			},
+		},
+		Format: "yaml",
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	result := eval.EvaluateRule(models.ValidationRule{Expression: "object.containers.all(c, has(c.memory))"})
+	expected := []models.EvaluatedObject{
+		{
+			Expression: "object.containers[1]",
+			Object:     map[string]interface{}{"name": "sidecar"},
+			Name:       "sidecar",
+		},
+	}
+	if !reflect.DeepEqual(result.EvaluatedObjects, expected) {
+		t.Errorf("Expected %v, got %v", expected, result.EvaluatedObjects)
+	}
+}
@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/parser"
+)
+
+// builtinMacros are the macros expanding to comprehensions over a range written by the user
+var builtinMacros = map[string]bool{
+	operators.All:       true,
+	operators.Exists:    true,
+	operators.ExistsOne: true,
+	operators.Map:       true,
+	operators.Filter:    true,
+}
+
+// objectExtractor collects the chains of selections rooted at the object or at a comprehension variable
+type objectExtractor struct {
+	info    *celast.SourceInfo
+	roots   map[string]bool
+	scopes  map[string]comprehensionScope
+	objects []string
+	seen    map[string]bool
+}
+
+// comprehensionScope is the range a comprehension variable iterates over
+type comprehensionScope struct {
+	iterRange string
+	parentVar string
+}
+
+// extractObjects returns the objects an expression reads, chains on comprehension variables becoming a map() of their range
+func extractObjects(ast *cel.Ast, roots ...string) []string {
+	expr, info, err := nativeAst(ast)
+	if err != nil {
+		return []string{}
+	}
+	x := &objectExtractor{
+		info:    info,
+		roots:   map[string]bool{},
+		scopes:  map[string]comprehensionScope{},
+		objects: []string{},
+		seen:    map[string]bool{},
+	}
+	for _, root := range roots {
+		x.roots[root] = true
+	}
+	x.walk(expr)
+	return x.objects
+}
+
+func nativeAst(ast *cel.Ast) (celast.Expr, *celast.SourceInfo, error) {
+	if ast == nil {
+		return nil, nil, fmt.Errorf("no ast to walk")
+	}
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return nil, nil, err
+	}
+	native, err := celast.ToAST(checked)
+	if err != nil {
+		return nil, nil, err
+	}
+	return native.Expr(), native.SourceInfo(), nil
+}
+
+func unparse(e celast.Expr, info *celast.SourceInfo) (string, error) {
+	return parser.Unparse(e, info, parser.WrapOnColumn(math.MaxInt32))
+}
+
+func (x *objectExtractor) walk(e celast.Expr) {
+	switch e.Kind() {
+	case celast.IdentKind:
+		if x.isRoot(e.AsIdent()) {
+			x.add(e, e.AsIdent())
+		}
+	case celast.SelectKind:
+		if root, ok := x.chainRoot(e); ok {
+			x.add(e, root)
+			return
+		}
+		x.walk(e.AsSelect().Operand())
+	case celast.CallKind:
+		if root, ok := x.chainRoot(e); ok {
+			x.add(e, root)
+			x.walk(e.AsCall().Args()[1])
+			return
+		}
+		call := e.AsCall()
+		if call.IsMemberFunction() {
+			x.walk(call.Target())
+		}
+		for _, arg := range call.Args() {
+			x.walk(arg)
+		}
+	case celast.ComprehensionKind:
+		if macroCall, ok := x.info.GetMacroCall(e.ID()); ok && macroCall.Kind() == celast.CallKind && !builtinMacros[macroCall.AsCall().FunctionName()] {
+			// other macros, like those of profiles, are read as written
+			for _, arg := range macroCall.AsCall().Args() {
+				x.walk(arg)
+			}
+			if x.scopedIdent(macroCall) == "" {
+				x.addMacro(macroCall)
+			}
+			return
+		}
+		comp := e.AsComprehension()
+		x.walk(comp.IterRange())
+		x.walk(comp.AccuInit())
+		iterRange, err := unparse(comp.IterRange(), x.info)
+		if err != nil {
+			return
+		}
+		previous, shadowed := x.scopes[comp.IterVar()]
+		x.scopes[comp.IterVar()] = comprehensionScope{
+			iterRange: iterRange,
+			parentVar: x.scopedIdent(comp.IterRange()),
+		}
+		x.walk(comp.LoopCondition())
+		x.walk(comp.LoopStep())
+		x.walk(comp.Result())
+		if shadowed {
+			x.scopes[comp.IterVar()] = previous
+		} else {
+			delete(x.scopes, comp.IterVar())
+		}
+	case celast.ListKind:
+		for _, elem := range e.AsList().Elements() {
+			x.walk(elem)
+		}
+	case celast.MapKind:
+		for _, entry := range e.AsMap().Entries() {
+			x.walk(entry.AsMapEntry().Key())
+			x.walk(entry.AsMapEntry().Value())
+		}
+	case celast.StructKind:
+		for _, field := range e.AsStruct().Fields() {
+			x.walk(field.AsStructField().Value())
+		}
+	}
+}
+
+// chainRoot returns the object or comprehension variable e selects or indexes into, if any
+func (x *objectExtractor) chainRoot(e celast.Expr) (string, bool) {
+	var root string
+	switch e.Kind() {
+	case celast.IdentKind:
+		name := e.AsIdent()
+		_, scoped := x.scopes[name]
+		return name, x.isRoot(name) || scoped
+	case celast.SelectKind:
+		if e.AsSelect().IsTestOnly() {
+			return "", false
+		}
+		return x.chainRoot(e.AsSelect().Operand())
+	case celast.CallKind:
+		call := e.AsCall()
+		if call.FunctionName() != operators.Index || len(call.Args()) != 2 {
+			return "", false
+		}
+		var ok bool
+		root, ok = x.chainRoot(call.Args()[0])
+		if !ok {
+			return "", false
+		}
+		if scoped := x.scopedIdent(call.Args()[1]); scoped != "" && scoped != root {
+			return "", false
+		}
+		return root, true
+	}
+	return "", false
+}
+
+func isObjectRoot(name string) bool {
+	return name == "object" || name == "oldObject" || name == "params" || strings.HasPrefix(name, VariablesPrefix)
+}
+
+func (x *objectExtractor) isRoot(name string) bool {
+	return isObjectRoot(name) || x.roots[name]
+}
+
+func (x *objectExtractor) scopedIdent(e celast.Expr) string {
+	found := ""
+	visitor := celast.NewExprVisitor(func(e celast.Expr) {
+		if found != "" || e.Kind() != celast.IdentKind {
+			return
+		}
+		if _, scoped := x.scopes[e.AsIdent()]; scoped {
+			found = e.AsIdent()
+		}
+	})
+	celast.PostOrderVisit(e, visitor)
+	return found
+}
+
+// addMacro adds macro calls that don't depend on comprehension variables
+func (x *objectExtractor) addMacro(call celast.Expr) {
+	object, err := unparse(call, x.info)
+	if err == nil && !x.seen[object] {
+		x.seen[object] = true
+		x.objects = append(x.objects, object)
+	}
+}
+
+func (x *objectExtractor) add(e celast.Expr, root string) {
+	object, err := unparse(e, x.info)
+	if err != nil {
+		return
+	}
+	for !x.isRoot(root) {
+		scope, ok := x.scopes[root]
+		if !ok {
+			break
+		}
+		object = fmt.Sprintf("%s.map(%s, %s)", scope.iterRange, root, object)
+		root = scope.parentVar
+	}
+	if !x.seen[object] {
+		x.seen[object] = true
+		x.objects = append(x.objects, object)
+	}
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractObjects(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			input:    "object",
+			expected: []string{"object"},
+		},
+		{
+			input:    "object.foo",
+			expected: []string{"object.foo"},
+		},
+		{
+			input:    "object.foo.bar",
+			expected: []string{"object.foo.bar"},
+		},
+		{
+			input:    "size(object.foo.bar.baz) > 0",
+			expected: []string{"object.foo.bar.baz"},
+		},
+		{
+			input:    "has(object.foo.bar.baz)",
+			expected: []string{"object.foo.bar"},
+		},
+		{
+			input:    "object.items[0].name == 'foo'",
+			expected: []string{"object.items[0].name"},
+		},
+		{
+			input:    "object.metadata.labels['app.kubernetes.io/name'] == 'foo'",
+			expected: []string{"object.metadata.labels[\"app.kubernetes.io/name\"]"},
+		},
+		{
+			input:    "object.foo == 'object.bar'",
+			expected: []string{"object.foo"},
+		},
+		{
+			input:    "object.foos[1].map(n, n * n).size() > 0 && object.bar == 'baz'",
+			expected: []string{"object.foos[1]", "object.bar"},
+		},
+		{
+			input:    "object.foos[1].map(n, n * n).size() > 0 || object.bar == 'baz' && size(object.foos) > 0",
+			expected: []string{"object.foos[1]", "object.bar", "object.foos"},
+		},
+		{
+			input:    "object.containers.all(c, c.image.startsWith('team-a') && c.name != '')",
+			expected: []string{"object.containers", "object.containers.map(c, c.image)", "object.containers.map(c, c.name)"},
+		},
+		{
+			input:    "object.containers.all(c, c.ports.all(p, p.containerPort > 1024))",
+			expected: []string{"object.containers", "object.containers.map(c, c.ports)", "object.containers.map(c, c.ports.map(p, p.containerPort))"},
+		},
+		{
+			input:    "object.labels.exists(k, object.labels[k] == 'foo')",
+			expected: []string{"object.labels"},
+		},
+		{
+			input:    "[1, 2].all(n, n > object.min)",
+			expected: []string{"object.min"},
+		},
+	}
+	eval, err := NewEvaluator(nil)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			compiled, err := eval.compileExpression(tc.input, nil)
+			if err != nil {
+				t.Fatalf("Error compiling expression: %v", err)
+			}
+			actual := extractObjects(compiled.ast)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
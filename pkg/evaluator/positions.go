@@ -0,0 +1,80 @@
+package evaluator
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"strings"
+
+	"github.com/google/cel-go/common/operators"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// objectPosition returns the position of the object an expression points at, or of its closest existing parent
+func (ev *Evaluator) objectPosition(expression string) *models.Position {
+	if ev.TargetData == nil || len(ev.TargetData.Positions) == 0 {
+		return nil
+	}
+	ast, issues := ev.env.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil
+	}
+	path, ok := ev.objectPath(ast.Expr())
+	if !ok {
+		return nil
+	}
+	for i := len(path); i >= 0; i-- {
+		if position, ok := ev.TargetData.Positions[helpers.PathKey(path[:i])]; ok {
+			return &position
+		}
+	}
+	return nil
+}
+
+// objectPath returns the path of a chain of selections and constant indexes rooted at the object or a variable defined as one
+func (ev *Evaluator) objectPath(expr *exprpb.Expr) ([]interface{}, bool) {
+	switch e := expr.GetExprKind().(type) {
+	case *exprpb.Expr_IdentExpr:
+		return []interface{}{}, e.IdentExpr.GetName() == "object"
+	case *exprpb.Expr_SelectExpr:
+		if e.SelectExpr.GetTestOnly() {
+			return nil, false
+		}
+		if e.SelectExpr.GetOperand().GetIdentExpr().GetName() == strings.TrimSuffix(VariablesPrefix, ".") {
+			return ev.variablePath(e.SelectExpr.GetField())
+		}
+		path, ok := ev.objectPath(e.SelectExpr.GetOperand())
+		return append(path, e.SelectExpr.GetField()), ok
+	case *exprpb.Expr_CallExpr:
+		call := e.CallExpr
+		if call.GetFunction() != operators.Index || len(call.GetArgs()) != 2 {
+			return nil, false
+		}
+		path, ok := ev.objectPath(call.GetArgs()[0])
+		if !ok {
+			return nil, false
+		}
+		switch index := call.GetArgs()[1].GetConstExpr().GetConstantKind().(type) {
+		case *exprpb.Constant_Int64Value:
+			return append(path, int(index.Int64Value)), true
+		case *exprpb.Constant_Uint64Value:
+			return append(path, int(index.Uint64Value)), true
+		case *exprpb.Constant_StringValue:
+			return append(path, index.StringValue), true
+		}
+	}
+	return nil, false
+}
+
+func (ev *Evaluator) variablePath(name string) ([]interface{}, bool) {
+	for _, variable := range ev.variables {
+		if variable.name != name {
+			continue
+		}
+		ast, issues := ev.env.Parse(variable.expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, false
+		}
+		return ev.objectPath(ast.Expr())
+	}
+	return nil, false
+}
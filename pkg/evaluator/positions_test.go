@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"testing"
+)
+
+func TestObjectPosition(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{"object": map[string]interface{}{}},
+		Positions: map[string]models.Position{
+			"":                               {Line: 1, Column: 1},
+			"spec":                           {Line: 2, Column: 1},
+			"spec.containers":                {Line: 3, Column: 3},
+			"spec.containers[1]":             {Line: 6, Column: 5},
+			"metadata":                       {Line: 9, Column: 1},
+			"metadata.labels":                {Line: 10, Column: 3},
+			"metadata.labels['app.io/name']": {Line: 11, Column: 5},
+		},
+	}
+	testCases := []struct {
+		expression string
+		expected   *models.Position
+	}{
+		{expression: "object.spec.containers", expected: &models.Position{Line: 3, Column: 3}},
+		{expression: "object.spec.containers[1]", expected: &models.Position{Line: 6, Column: 5}},
+		{expression: "object.metadata.labels['app.io/name']", expected: &models.Position{Line: 11, Column: 5}},
+		{expression: "object.spec.containers[1].resources.limits", expected: &models.Position{Line: 6, Column: 5}},
+		{expression: "size(object.spec.containers)", expected: nil},
+		{expression: "other.spec", expected: nil},
+		{expression: "variables.containers[1]", expected: &models.Position{Line: 6, Column: 5}},
+		{expression: "variables.firstImage", expected: &models.Position{Line: 3, Column: 3}},
+		{expression: "variables.count", expected: nil},
+		{expression: "oldObject.spec", expected: nil},
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	err = eval.DeclareVariables([]models.Variable{
+		{Name: "containers", Expression: "object.spec.containers"},
+		{Name: "firstImage", Expression: "variables.containers[0].image"},
+		{Name: "count", Expression: "size(object.spec.containers)"},
+	})
+	if err != nil {
+		t.Fatalf("Error declaring variables: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			actual := eval.objectPosition(tc.expression)
+			if (actual == nil) != (tc.expected == nil) || (actual != nil && *actual != *tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVariableRulePositions(t *testing.T) {
+	target := &models.TargetData{
+		Data: map[string]interface{}{"object": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "team-a/app"},
+				map[string]interface{}{"name": "sidecar", "image": "proxy"},
+			}},
+		}},
+		Positions: map[string]models.Position{
+			"":                   {Line: 1, Column: 1},
+			"spec.containers":    {Line: 2, Column: 3},
+			"spec.containers[0]": {Line: 3, Column: 5},
+			"spec.containers[1]": {Line: 5, Column: 5},
+		},
+	}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	results, err := eval.Evaluate(models.ValidationConfig{
+		Variables:   []models.Variable{{Name: "containers", Expression: "object.spec.containers"}},
+		Validations: []models.ValidationRule{{Expression: "variables.containers.all(c, c.image.startsWith('team-a'))"}},
+	})
+	if err != nil {
+		t.Fatalf("Error evaluating validations: %v", err)
+	}
+	if len(results) != 1 || len(results[0].EvaluatedObjects) != 1 {
+		t.Fatalf("Expected a single failing element, got %+v", results)
+	}
+	obj := results[0].EvaluatedObjects[0]
+	if obj.Expression != "variables.containers[1]" || obj.Position == nil || *obj.Position != (models.Position{Line: 5, Column: 5}) {
+		t.Errorf("Expected variables.containers[1] at 5:5, got %s at %v", obj.Expression, obj.Position)
+	}
+}
@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Profile tailors expressions to a kind of target with variables computed from its object and macros
+type Profile struct {
+	Name      string
+	Variables map[string]*cel.Type
+	Macros    []cel.Macro
+	// Compute returns the value of every variable for the object of a target
+	Compute func(object interface{}) map[string]interface{}
+}
+
+// UseProfile declares the variables and macros of the profile, before expressions are compiled
+func (ev *Evaluator) UseProfile(profile *Profile) error {
+	options := []cel.EnvOption{cel.Macros(profile.Macros...)}
+	for name, varType := range profile.Variables {
+		options = append(options, cel.Variable(name, varType))
+	}
+	env, err := ev.env.Extend(options...)
+	if err != nil {
+		return fmt.Errorf("profile '%s': %v", profile.Name, err)
+	}
+	ev.env = env
+	ev.profile = profile
+	return nil
+}
+
+func (ev *Evaluator) profileRoots() []string {
+	if ev.profile == nil {
+		return nil
+	}
+	roots := make([]string, 0, len(ev.profile.Variables))
+	for name := range ev.profile.Variables {
+		roots = append(roots, name)
+	}
+	return roots
+}
+
+// profileVariables computes the profile variables once per target
+type profileVariables struct {
+	profile *Profile
+	object  interface{}
+	once    sync.Once
+	values  map[string]interface{}
+}
+
+func (p *profileVariables) value(name string) (interface{}, bool) {
+	if p.profile == nil {
+		return nil, false
+	}
+	if _, ok := p.profile.Variables[name]; !ok {
+		return nil, false
+	}
+	p.once.Do(func() {
+		p.values = p.profile.Compute(p.object)
+	})
+	return p.values[name], true
+}
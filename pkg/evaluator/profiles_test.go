@@ -0,0 +1,70 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/parser"
+)
+
+// testProfile exposes the object items as items, along with itemsNamed(name) expanding to a filter over them
+var testProfile = &Profile{
+	Name:      "test",
+	Variables: map[string]*cel.Type{"items": cel.ListType(cel.DynType)},
+	Macros: []cel.Macro{
+		parser.NewGlobalMacro("itemsNamed", 1, func(eh parser.ExprHelper, _ ast.Expr, args []ast.Expr) (ast.Expr, *common.Error) {
+			predicate := eh.NewCall(operators.Equals, eh.NewSelect(eh.NewIdent("__item__"), "name"), args[0])
+			return parser.MakeFilter(eh, eh.NewIdent("items"), []ast.Expr{eh.NewIdent("__item__"), predicate})
+		}),
+	},
+	Compute: func(object interface{}) map[string]interface{} {
+		items, _ := object.(map[string]interface{})["items"].([]interface{})
+		return map[string]interface{}{"items": items}
+	},
+}
+
+func TestProfile(t *testing.T) {
+	runner, err := NewRunner(2)
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	if err := runner.Evaluator.UseProfile(testProfile); err != nil {
+		t.Fatalf("Error using profile: %v", err)
+	}
+	ruleSet, err := runner.Compile(models.ValidationConfig{
+		Validations: []models.ValidationRule{
+			{Expression: "size(items) == 3"},
+			{Expression: "itemsNamed('b').all(i, i.enabled)"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error compiling validations: %v", err)
+	}
+	target := &models.TargetData{
+		Data: map[string]interface{}{
+			"object": map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"name": "a", "enabled": false},
+					map[string]interface{}{"name": "b", "enabled": true},
+					map[string]interface{}{"name": "b", "enabled": false},
+				},
+			},
+		},
+		Format: "json",
+	}
+	results := runner.Run(ruleSet, []*models.TargetData{target})[0].Results
+	if err := results[0].ValidationError; err != nil {
+		t.Errorf("Expected profile variables to be computed from the object, got %v", err)
+	}
+	failed := results[1]
+	if failed.ValidationError == nil {
+		t.Fatalf("Expected the macro rule to fail")
+	}
+	if len(failed.EvaluatedObjects) != 1 || failed.EvaluatedObjects[0].Expression != `itemsNamed("b")[1]` {
+		t.Errorf("Expected the failing element of the macro to be reported, got %#v", failed.EvaluatedObjects)
+	}
+}
@@ -0,0 +1,95 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/hashicorp/go-multierror"
+)
+
+// RuleSet is a set of validations compiled once, ready to be evaluated against any number of targets
+type RuleSet struct {
+	Rules []CompiledRule
+}
+
+type CompiledRule struct {
+	Rule            models.ValidationRule
+	matchConditions []compiledCondition
+	ast             *cel.Ast
+	program         cel.Program
+	messageProgram  cel.Program
+}
+
+// Compile compiles every rule of the validations, reporting the errors of all rules at once
+func (ev *Evaluator) Compile(validations models.ValidationConfig) (*RuleSet, error) {
+	ruleSet := &RuleSet{}
+	multiErr := &multierror.Error{}
+	ids := map[string]int{}
+	if err := ev.DeclareVariables(validations.Variables); err != nil {
+		multiErr = multierror.Append(multiErr, err)
+	}
+	conditions, errs := ev.compileConditions(validations.MatchConditions)
+	for _, err := range errs {
+		multiErr = multierror.Append(multiErr, err)
+	}
+	for i, rule := range validations.Validations {
+		if rule.ID != "" {
+			if first, ok := ids[rule.ID]; ok {
+				multiErr = multierror.Append(multiErr, fmt.Errorf("rule %d: id '%s' is already used by rule %d", i+1, rule.ID, first))
+			} else {
+				ids[rule.ID] = i + 1
+			}
+		}
+		if !validSeverity(rule.Severity) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("rule %d: invalid severity '%s', expected one of: %s", i+1, rule.Severity, strings.Join(models.Severities, ", ")))
+		}
+		compiled, errs := ev.compileRule(rule, conditions)
+		for _, err := range errs {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("rule %d: %v", i+1, err))
+		}
+		ruleSet.Rules = append(ruleSet.Rules, compiled)
+	}
+	if err := multiErr.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+	return ruleSet, nil
+}
+
+// compileRule compiles a rule and its match conditions, checked after the shared ones
+func (ev *Evaluator) compileRule(rule models.ValidationRule, shared []compiledCondition) (CompiledRule, []error) {
+	compiled := CompiledRule{Rule: rule}
+	conditions, errs := ev.compileConditions(rule.MatchConditions)
+	compiled.matchConditions = append(append([]compiledCondition{}, shared...), conditions...)
+	expression, err := ev.compileExpression(rule.Expression, cel.BoolType)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	compiled.program = expression.program
+	compiled.ast = expression.ast
+	if rule.MessageExpression != "" {
+		message, err := ev.compileExpression(rule.MessageExpression, cel.StringType)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		compiled.messageProgram = message.program
+	}
+	return compiled, errs
+}
+
+func (c CompiledRule) evaluable(shared []compiledCondition) bool {
+	return c.program != nil && len(c.matchConditions) == len(shared)+len(c.Rule.MatchConditions)
+}
+
+func validSeverity(severity string) bool {
+	if severity == "" {
+		return true
+	}
+	for _, supported := range models.Severities {
+		if severity == supported {
+			return true
+		}
+	}
+	return false
+}
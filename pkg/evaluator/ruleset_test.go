@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		validations    models.ValidationConfig
+		expectedErrors []string
+	}{
+		{
+			name: "valid rules",
+			validations: models.ValidationConfig{
+				Validations: []models.ValidationRule{
+					{Expression: "object.foo == 'bar'", MessageExpression: "'foo is ' + object.foo"},
+					{Expression: "object.enabled"},
+				},
+			},
+		},
+		{
+			name: "every invalid rule is reported",
+			validations: models.ValidationConfig{
+				Validations: []models.ValidationRule{
+					{Expression: "object.foo == "},
+					{Expression: "object.foo == 'bar'"},
+					{Expression: "1 + 1"},
+					{Expression: "object.foo == 'bar'", MessageExpression: "1"},
+				},
+			},
+			expectedErrors: []string{"rule 1:", "rule 3:", "rule 4:"},
+		},
+		{
+			name: "duplicate ids are reported",
+			validations: models.ValidationConfig{
+				Validations: []models.ValidationRule{
+					{ID: "replicas", Expression: "object.replicas > 1"},
+					{ID: "image", Expression: "object.image != ''"},
+					{ID: "replicas", Expression: "object.replicas < 10"},
+				},
+			},
+			expectedErrors: []string{"rule 3: id 'replicas' is already used by rule 1"},
+		},
+		{
+			name: "invalid severities are reported",
+			validations: models.ValidationConfig{
+				Validations: []models.ValidationRule{
+					{Severity: "critical", Expression: "object.replicas < 10"},
+					{Severity: models.SeverityWarning, Expression: "object.replicas > 1"},
+				},
+			},
+			expectedErrors: []string{"rule 1: invalid severity 'critical'"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eval, err := NewEvaluator(nil)
+			if err != nil {
+				t.Fatalf("Error creating evaluator: %v", err)
+			}
+			ruleSet, err := eval.Compile(tc.validations)
+			if len(tc.expectedErrors) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if len(ruleSet.Rules) != len(tc.validations.Validations) {
+					t.Errorf("Expected %d compiled rules, got %d", len(tc.validations.Validations), len(ruleSet.Rules))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected errors, got none")
+			}
+			for _, expected := range tc.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain '%s', got %v", expected, err)
+				}
+			}
+			if strings.Contains(err.Error(), "rule 2:") {
+				t.Errorf("Expected rule 2 to compile, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"runtime"
+	"sync"
+)
+
+// Runner evaluates a compiled rule set against many targets using a pool of workers, keeping the targets order
+type Runner struct {
+	Evaluator   *Evaluator
+	Concurrency int
+}
+
+type job struct {
+	target int
+	rule   int
+}
+
+func NewRunner(concurrency int) (*Runner, error) {
+	eval, err := NewEvaluator(nil)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	return &Runner{
+		Evaluator:   eval,
+		Concurrency: concurrency,
+	}, nil
+}
+
+// Compile compiles the validations once for all workers
+func (r *Runner) Compile(validations models.ValidationConfig) (*RuleSet, error) {
+	return r.Evaluator.Compile(validations)
+}
+
+func (r *Runner) Run(ruleSet *RuleSet, targets []*models.TargetData) []models.TargetResult {
+	targetResults := make([]models.TargetResult, len(targets))
+	evaluators := make([]*Evaluator, len(targets))
+	for i, target := range targets {
+		targetResults[i] = models.TargetResult{
+			Target:  target,
+			Results: make([]models.EvaluationResult, len(ruleSet.Rules)),
+		}
+		evaluators[i] = r.Evaluator.ForTarget(target)
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < r.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				compiled := ruleSet.Rules[j.rule]
+				targetResults[j.target].Results[j.rule] = evaluators[j.target].EvaluateCompiledRule(compiled)
+			}
+		}()
+	}
+	for t := range targets {
+		for rule := range ruleSet.Rules {
+			jobs <- job{target: t, rule: rule}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return targetResults
+}
@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"fmt"
+	"testing"
+)
+
+func TestRunnerKeepsInputOrder(t *testing.T) {
+	targets := []*models.TargetData{}
+	for i := 0; i < 50; i++ {
+		targets = append(targets, &models.TargetData{
+			Data: map[string]interface{}{
+				"object": map[string]interface{}{
+					"index": i,
+				},
+			},
+			Format:   "yaml",
+			Document: i,
+		})
+	}
+	validations := models.ValidationConfig{
+		Validations: []models.ValidationRule{
+			{Expression: "object.index % 2 == 0"},
+			{Expression: "object.index >= 0"},
+		},
+	}
+	for _, concurrency := range []int{1, 8} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			runner, err := NewRunner(concurrency)
+			if err != nil {
+				t.Fatalf("Error creating runner: %v", err)
+			}
+			ruleSet, err := runner.Compile(validations)
+			if err != nil {
+				t.Fatalf("Error compiling validations: %v", err)
+			}
+			targetResults := runner.Run(ruleSet, targets)
+			if len(targetResults) != len(targets) {
+				t.Fatalf("Expected %d target results, got %d", len(targets), len(targetResults))
+			}
+			for i, targetResult := range targetResults {
+				if targetResult.Target != targets[i] {
+					t.Errorf("Expected target %d, got target %d", i, targetResult.Target.Document)
+				}
+				if len(targetResult.Results) != len(validations.Validations) {
+					t.Fatalf("Expected %d results, got %d", len(validations.Validations), len(targetResult.Results))
+				}
+				for j, result := range targetResult.Results {
+					if result.Expression != validations.Validations[j].Expression {
+						t.Errorf("Expected expression '%s', got '%s'", validations.Validations[j].Expression, result.Expression)
+					}
+				}
+				if failed := targetResult.Results[0].ValidationError != nil; failed != (i%2 != 0) {
+					t.Errorf("Unexpected result for target %d: %v", i, targetResult.Results[0].ValidationError)
+				}
+			}
+			if len(runner.Evaluator.programs.programs) < len(validations.Validations) {
+				t.Errorf("Expected every rule to be compiled once and cached")
+			}
+		})
+	}
+}
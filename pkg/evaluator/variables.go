@@ -0,0 +1,118 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter"
+	"github.com/hashicorp/go-multierror"
+)
+
+// VariablesPrefix is what variables are referenced by in expressions, e.g. variables.containers
+const VariablesPrefix = "variables."
+
+var variableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type compiledVariable struct {
+	name       string
+	expression string
+	program    cel.Program
+}
+
+// DeclareVariables compiles and declares the variables in order, the ones failing to compile as dyn
+func (ev *Evaluator) DeclareVariables(variables []models.Variable) error {
+	multiErr := &multierror.Error{}
+	declared := map[string]bool{}
+	for _, variable := range variables {
+		if !variableNamePattern.MatchString(variable.Name) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable '%s': name must be a valid identifier", variable.Name))
+			continue
+		}
+		if declared[variable.Name] {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable '%s': already declared", variable.Name))
+			continue
+		}
+		declared[variable.Name] = true
+		varType := cel.DynType
+		compiled, err := ev.compileExpression(variable.Expression, nil)
+		if err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable '%s': %v", variable.Name, err))
+		} else {
+			varType = compiled.ast.OutputType()
+			ev.variables = append(ev.variables, compiledVariable{name: variable.Name, expression: variable.Expression, program: compiled.program})
+		}
+		env, err := ev.env.Extend(cel.Variable(VariablesPrefix+variable.Name, varType))
+		if err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable '%s': %v", variable.Name, err))
+			continue
+		}
+		ev.env = env
+	}
+	return multiErr.ErrorOrNil()
+}
+
+// activation returns the data, params and variables of the target, variables being evaluated when first referenced
+func (ev *Evaluator) activation() interpreter.Activation {
+	ev.activationOnce.Do(func() {
+		activation := &variableActivation{
+			data:      ev.TargetData.Data,
+			params:    ev.Params,
+			variables: map[string]*lazyVariable{},
+			profile:   &profileVariables{profile: ev.profile, object: ev.TargetData.Data["object"]},
+		}
+		for _, variable := range ev.variables {
+			activation.variables[VariablesPrefix+variable.name] = &lazyVariable{compiledVariable: variable}
+		}
+		ev.vars = activation
+	})
+	return ev.vars
+}
+
+type variableActivation struct {
+	data      map[string]interface{}
+	params    interface{}
+	variables map[string]*lazyVariable
+	profile   *profileVariables
+}
+
+func (a *variableActivation) ResolveName(name string) (interface{}, bool) {
+	if variable, ok := a.variables[name]; ok {
+		return variable.value(a), true
+	}
+	if name == "params" {
+		// params is null when none were given, as in admission policies without a param kind
+		return a.params, true
+	}
+	if value, ok := a.profile.value(name); ok {
+		return value, true
+	}
+	value, ok := a.data[name]
+	return value, ok
+}
+
+func (a *variableActivation) Parent() interpreter.Activation {
+	return nil
+}
+
+type lazyVariable struct {
+	compiledVariable
+	once sync.Once
+	val  ref.Val
+}
+
+func (v *lazyVariable) value(activation interpreter.Activation) ref.Val {
+	v.once.Do(func() {
+		out, _, err := v.program.Eval(activation)
+		if err != nil {
+			v.val = types.NewErr("error evaluating variable '%s': %v", v.name, err)
+			return
+		}
+		v.val = out
+	})
+	return v.val
+}
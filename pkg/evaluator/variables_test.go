@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"celify/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestVariables(t *testing.T) {
+	validations := models.ValidationConfig{
+		Variables: []models.Variable{
+			{Name: "containers", Expression: "object.spec.containers + object.spec.initContainers"},
+			{Name: "images", Expression: "variables.containers.map(c, c.image)"},
+		},
+		Validations: []models.ValidationRule{
+			{
+				Expression:        "variables.images.all(i, i.startsWith('team-a'))",
+				MessageExpression: "'found ' + string(size(variables.containers)) + ' containers'",
+			},
+			{Expression: "size(variables.containers) == 2"},
+		},
+	}
+	targets := []*models.TargetData{}
+	for _, image := range []string{"team-a/init", "nginx"} {
+		targets = append(targets, &models.TargetData{
+			Data: map[string]interface{}{
+				"object": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers":     []interface{}{map[string]interface{}{"name": "app", "image": "team-a/app"}},
+						"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": image}},
+					},
+				},
+			},
+			Format: "yaml",
+		})
+	}
+	runner, err := NewRunner(4)
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	ruleSet, err := runner.Compile(validations)
+	if err != nil {
+		t.Fatalf("Error compiling validations: %v", err)
+	}
+	targetResults := runner.Run(ruleSet, targets)
+	if err := targetResults[0].Results[0].ValidationError; err != nil {
+		t.Errorf("Expected first target to pass, got %v", err)
+	}
+	failed := targetResults[1].Results[0]
+	if failed.ValidationError == nil || failed.Message != "found 2 containers" {
+		t.Errorf("Expected second target to fail with message 'found 2 containers', got %v", failed.ValidationError)
+	}
+	if len(failed.EvaluatedObjects) != 1 || failed.EvaluatedObjects[0].Expression != "variables.images[1]" || failed.EvaluatedObjects[0].Object != "nginx" {
+		t.Errorf("Expected the failing image to be reported, got %#v", failed.EvaluatedObjects)
+	}
+	for i, targetResult := range targetResults {
+		if err := targetResult.Results[1].ValidationError; err != nil {
+			t.Errorf("Expected variables to be usable in every rule of target %d, got %v", i, err)
+		}
+	}
+}
+
+func TestDeclareVariablesErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		variables     []models.Variable
+		expectedError string
+	}{
+		{
+			name:          "invalid name",
+			variables:     []models.Variable{{Name: "my-var", Expression: "1"}},
+			expectedError: "variable 'my-var': name must be a valid identifier",
+		},
+		{
+			name:          "duplicate name",
+			variables:     []models.Variable{{Name: "a", Expression: "1"}, {Name: "a", Expression: "2"}},
+			expectedError: "variable 'a': already declared",
+		},
+		{
+			name:          "invalid expression",
+			variables:     []models.Variable{{Name: "a", Expression: "object.foo =="}},
+			expectedError: "variable 'a': Failed to compile expression",
+		},
+		{
+			name:          "undeclared variable",
+			variables:     []models.Variable{{Name: "a", Expression: "variables.b"}, {Name: "b", Expression: "1"}},
+			expectedError: "variable 'a': Failed to compile expression",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eval, err := NewEvaluator(nil)
+			if err != nil {
+				t.Fatalf("Error creating evaluator: %v", err)
+			}
+			err = eval.DeclareVariables(tc.variables)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error to contain '%s', got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestVariablesAreEvaluatedLazily(t *testing.T) {
+	target := &models.TargetData{Data: map[string]interface{}{"object": map[string]interface{}{}}}
+	eval, err := NewEvaluator(target)
+	if err != nil {
+		t.Fatalf("Error creating evaluator: %v", err)
+	}
+	results, err := eval.Evaluate(models.ValidationConfig{
+		Variables: []models.Variable{
+			{Name: "missing", Expression: "object.missing"},
+		},
+		Validations: []models.ValidationRule{
+			{Expression: "true || variables.missing == 1"},
+			{Expression: "variables.missing == 1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error evaluating validations: %v", err)
+	}
+	if results[0].ValidationError != nil {
+		t.Errorf("Expected unused variable not to be evaluated, got %v", results[0].ValidationError)
+	}
+	if results[1].ValidationError == nil || !strings.Contains(results[1].ValidationError.Error(), "error evaluating variable 'missing'") {
+		t.Errorf("Expected variable evaluation error, got %v", results[1].ValidationError)
+	}
+}
@@ -0,0 +1,305 @@
+package helpers
+
+import (
+	"celify/pkg/models"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// hclConverter converts an HCL body into the object, recording positions when positions isn't nil
+type hclConverter struct {
+	src       []byte
+	file      string
+	positions map[string]models.Position
+}
+
+func hclDocuments(data []byte) ([]interface{}, error) {
+	body, err := parseHCL(data, "")
+	if err != nil {
+		return nil, err
+	}
+	c := hclConverter{src: data}
+	return []interface{}{c.body(body, []interface{}{})}, nil
+}
+
+// HCLPositions returns the position of every node of an HCL file keyed by PathKey
+func HCLPositions(data []byte, file string) []map[string]models.Position {
+	body, err := parseHCL(data, file)
+	if err != nil {
+		return nil
+	}
+	c := hclConverter{src: data, file: file, positions: map[string]models.Position{}}
+	c.record([]interface{}{}, body.SrcRange.Start)
+	c.body(body, []interface{}{})
+	return []map[string]models.Position{c.positions}
+}
+
+func parseHCL(data []byte, file string) (*hclsyntax.Body, error) {
+	f, diags := hclsyntax.ParseConfig(data, file, hcl.InitialPos)
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		if diag.Subject == nil {
+			return nil, errors.Errorf("Error unmarshalling target: hcl: %s; %s", diag.Summary, diag.Detail)
+		}
+		return nil, errors.Errorf("Error unmarshalling target: hcl: line %d, column %d: %s; %s", diag.Subject.Start.Line, diag.Subject.Start.Column, diag.Summary, diag.Detail)
+	}
+	return f.Body.(*hclsyntax.Body), nil
+}
+
+// body converts blocks into lists of bodies keyed by type and labels, e.g. resource.aws_s3_bucket.logs[0]
+func (c hclConverter) body(body *hclsyntax.Body, path []interface{}) map[string]interface{} {
+	obj := map[string]interface{}{}
+	for name, attr := range body.Attributes {
+		attrPath := appendSegment(path, name)
+		c.record(attrPath, attr.NameRange.Start)
+		obj[name] = c.expression(attr.Expr, attrPath)
+	}
+	for _, block := range body.Blocks {
+		keys := append([]string{block.Type}, block.Labels...)
+		parent := obj
+		blockPath := path
+		for i, key := range keys[:len(keys)-1] {
+			blockPath = appendSegment(blockPath, key)
+			c.recordFirst(blockPath, labelStart(block, i))
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		last := keys[len(keys)-1]
+		blockPath = appendSegment(blockPath, last)
+		c.recordFirst(blockPath, labelStart(block, len(keys)-1))
+		list, _ := parent[last].([]interface{})
+		blockPath = appendSegment(blockPath, len(list))
+		c.record(blockPath, block.TypeRange.Start)
+		parent[last] = append(list, c.body(block.Body, blockPath))
+	}
+	return obj
+}
+
+// labelStart returns the start of the block type for i 0, else of label i-1
+func labelStart(block *hclsyntax.Block, i int) hcl.Pos {
+	if i == 0 || i > len(block.LabelRanges) {
+		return block.TypeRange.Start
+	}
+	return block.LabelRanges[i-1].Start
+}
+
+// expression returns the value of an expression when statically known, else its source as a ${...} string
+func (c hclConverter) expression(expr hclsyntax.Expression, path []interface{}) interface{} {
+	switch e := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		list := make([]interface{}, 0, len(e.Exprs))
+		for i, element := range e.Exprs {
+			elementPath := appendSegment(path, i)
+			c.record(elementPath, element.Range().Start)
+			list = append(list, c.expression(element, elementPath))
+		}
+		return list
+	case *hclsyntax.ObjectConsExpr:
+		obj := map[string]interface{}{}
+		for _, item := range e.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !key.IsWhollyKnown() || key.IsNull() || key.Type() != cty.String {
+				obj[c.source(item.KeyExpr)] = c.expression(item.ValueExpr, appendSegment(path, c.source(item.KeyExpr)))
+				continue
+			}
+			itemPath := appendSegment(path, key.AsString())
+			c.record(itemPath, item.KeyExpr.Range().Start)
+			obj[key.AsString()] = c.expression(item.ValueExpr, itemPath)
+		}
+		return obj
+	}
+	val, diags := expr.Value(nil)
+	if !diags.HasErrors() && val.IsWhollyKnown() {
+		return ctyToNative(val)
+	}
+	source := string(expr.Range().SliceBytes(c.src))
+	if _, isTemplate := expr.(*hclsyntax.TemplateExpr); isTemplate && strings.HasPrefix(source, `"`) {
+		return strings.TrimSuffix(strings.TrimPrefix(source, `"`), `"`)
+	}
+	if _, isWrap := expr.(*hclsyntax.TemplateWrapExpr); isWrap && strings.HasPrefix(source, `"`) {
+		return strings.TrimSuffix(strings.TrimPrefix(source, `"`), `"`)
+	}
+	return fmt.Sprintf("${%s}", source)
+}
+
+func (c hclConverter) source(expr hclsyntax.Expression) string {
+	return string(expr.Range().SliceBytes(c.src))
+}
+
+func (c hclConverter) record(path []interface{}, pos hcl.Pos) {
+	if c.positions != nil {
+		c.positions[PathKey(path)] = models.Position{File: c.file, Line: pos.Line, Column: pos.Column}
+	}
+}
+
+func (c hclConverter) recordFirst(path []interface{}, pos hcl.Pos) {
+	if c.positions == nil {
+		return
+	}
+	if _, ok := c.positions[PathKey(path)]; !ok {
+		c.record(path, pos)
+	}
+}
+
+func ctyToNative(val cty.Value) interface{} {
+	if val.IsNull() {
+		return nil
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString()
+	case ty == cty.Bool:
+		return val.True()
+	case ty == cty.Number:
+		number := val.AsBigFloat()
+		if i, accuracy := number.Int64(); accuracy == big.Exact {
+			return i
+		}
+		f, _ := number.Float64()
+		return f
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		list := []interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			list = append(list, ctyToNative(element))
+		}
+		return list
+	case ty.IsMapType() || ty.IsObjectType():
+		obj := map[string]interface{}{}
+		for it := val.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			obj[key.AsString()] = ctyToNative(element)
+		}
+		return obj
+	}
+	return nil
+}
+
+// marshalHCL renders maps keyed by identifiers as attributes and other values as an expression
+func marshalHCL(target interface{}) ([]byte, error) {
+	target = Normalize(target)
+	obj, isMap := target.(map[string]interface{})
+	if !isMap || !allIdentifiers(obj) {
+		tokens, err := hclTokens(target)
+		if err != nil {
+			return nil, err
+		}
+		return hclwrite.Format(append(tokens.Bytes(), '\n')), nil
+	}
+	f := hclwrite.NewEmptyFile()
+	for _, key := range sortedKeys(obj) {
+		tokens, err := hclTokens(obj[key])
+		if err != nil {
+			return nil, err
+		}
+		f.Body().SetAttributeRaw(key, tokens)
+	}
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+// hclTokens renders a value as an HCL expression, strings holding expressions converted as ${...} being written as is
+func hclTokens(value interface{}) (hclwrite.Tokens, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		elements := make([]hclwrite.Tokens, 0, len(v))
+		for _, item := range v {
+			tokens, err := hclTokens(item)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, tokens)
+		}
+		return hclwrite.TokensForTuple(elements), nil
+	case map[string]interface{}:
+		attrs := make([]hclwrite.ObjectAttrTokens, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			tokens, err := hclTokens(v[key])
+			if err != nil {
+				return nil, err
+			}
+			name := hclwrite.TokensForValue(cty.StringVal(key))
+			if hclsyntax.ValidIdentifier(key) {
+				name = hclwrite.TokensForIdentifier(key)
+			}
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: name, Value: tokens})
+		}
+		return hclwrite.TokensForObject(attrs), nil
+	case string:
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
+			if tokens, ok := expressionTokens(v[2 : len(v)-1]); ok {
+				return tokens, nil
+			}
+		}
+		if strings.Contains(v, "${") || strings.Contains(v, "%{") {
+			if tokens, ok := expressionTokens(`"` + v + `"`); ok {
+				return tokens, nil
+			}
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return nil, err
+	}
+	val, err := ctyjson.Unmarshal(data, ty)
+	if err != nil {
+		return nil, err
+	}
+	return hclwrite.TokensForValue(val), nil
+}
+
+// expressionTokens lexes the source of an expression, ok being false when it isn't a valid expression
+func expressionTokens(source string) (hclwrite.Tokens, bool) {
+	if _, diags := hclsyntax.ParseExpression([]byte(source), "", hcl.InitialPos); diags.HasErrors() {
+		return nil, false
+	}
+	lexed, diags := hclsyntax.LexExpression([]byte(source), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	tokens := hclwrite.Tokens{}
+	for _, token := range lexed {
+		if token.Type != hclsyntax.TokenEOF {
+			tokens = append(tokens, &hclwrite.Token{Type: token.Type, Bytes: token.Bytes})
+		}
+	}
+	return tokens, true
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func allIdentifiers(obj map[string]interface{}) bool {
+	for key := range obj {
+		if !hclsyntax.ValidIdentifier(key) {
+			return false
+		}
+	}
+	return true
+}
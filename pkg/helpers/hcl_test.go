@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"celify/pkg/models"
+	"reflect"
+	"testing"
+)
+
+const hclSource = `resource "aws_s3_bucket" "logs" {
+  bucket = "logs-${var.env}"
+  ports  = [80, var.port]
+  tags = {
+    Team = "platform"
+  }
+  versioning {
+    enabled = true
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "more-logs"
+}
+
+locals {
+  region = var.region
+}
+`
+
+func TestHCLDocuments(t *testing.T) {
+	docs, format, err := UnmarshalDocumentsAs([]byte(hclSource), FormatHCL)
+	if err != nil {
+		t.Fatalf("Error unmarshalling documents: %v", err)
+	}
+	if format != FormatHCL {
+		t.Errorf("Expected format '%s', got '%s'", FormatHCL, format)
+	}
+	expected := map[string]interface{}{
+		"resource": map[string]interface{}{
+			"aws_s3_bucket": map[string]interface{}{
+				"logs": []interface{}{
+					map[string]interface{}{
+						"bucket":     "logs-${var.env}",
+						"ports":      []interface{}{int64(80), "${var.port}"},
+						"tags":       map[string]interface{}{"Team": "platform"},
+						"versioning": []interface{}{map[string]interface{}{"enabled": true}},
+					},
+					map[string]interface{}{"bucket": "more-logs"},
+				},
+			},
+		},
+		"locals": []interface{}{map[string]interface{}{"region": "${var.region}"}},
+	}
+	if len(docs) != 1 || !reflect.DeepEqual(docs[0], expected) {
+		t.Errorf("Expected %v, got %v", expected, docs)
+	}
+}
+
+func TestHCLPositions(t *testing.T) {
+	positions := HCLPositions([]byte(hclSource), "main.tf")
+	if len(positions) != 1 {
+		t.Fatalf("Expected positions for 1 document, got %d", len(positions))
+	}
+	expected := map[string]models.Position{
+		"resource":                                     {File: "main.tf", Line: 1, Column: 1},
+		"resource.aws_s3_bucket.logs[0]":               {File: "main.tf", Line: 1, Column: 1},
+		"resource.aws_s3_bucket.logs[0].ports[1]":      {File: "main.tf", Line: 3, Column: 17},
+		"resource.aws_s3_bucket.logs[0].tags.Team":     {File: "main.tf", Line: 5, Column: 5},
+		"resource.aws_s3_bucket.logs[0].versioning":    {File: "main.tf", Line: 7, Column: 3},
+		"resource.aws_s3_bucket.logs[1].bucket":        {File: "main.tf", Line: 13, Column: 3},
+		"locals[0].region":                             {File: "main.tf", Line: 17, Column: 3},
+		"resource.aws_s3_bucket.logs":                  {File: "main.tf", Line: 1, Column: 26},
+		"resource.aws_s3_bucket":                       {File: "main.tf", Line: 1, Column: 10},
+		"resource.aws_s3_bucket.logs[0].versioning[0]": {File: "main.tf", Line: 7, Column: 3},
+	}
+	for key, position := range expected {
+		if actual, ok := positions[0][key]; !ok || actual != position {
+			t.Errorf("Expected %s at %v, got %v", key, position, actual)
+		}
+	}
+}
+
+func TestMarshalHCL(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{
+			input:    map[string]interface{}{"bucket": "logs", "versioning": []interface{}{map[string]interface{}{"enabled": true}}},
+			expected: "bucket = \"logs\"\nversioning = [{\n  enabled = true\n}]\n",
+		},
+		{
+			input:    map[string]interface{}{"app.kubernetes.io/name": "celify"},
+			expected: "{\n  \"app.kubernetes.io/name\" = \"celify\"\n}\n",
+		},
+		{
+			input:    "logs",
+			expected: "\"logs\"\n",
+		},
+		{
+			input: map[string]interface{}{
+				"bucket": "${var.name}",
+				"tags":   map[string]interface{}{"env": "${upper(var.env)}", "team": "platform"},
+				"name":   "logs-${var.env}",
+				"cidrs":  []interface{}{"${local.cidr}", "10.0.0.0/8"},
+			},
+			expected: "bucket = var.name\ncidrs  = [local.cidr, \"10.0.0.0/8\"]\nname   = \"logs-${var.env}\"\ntags = {\n  env  = upper(var.env)\n  team = \"platform\"\n}\n",
+		},
+		{
+			input:    "${var.name",
+			expected: "\"$${var.name\"\n",
+		},
+	}
+	for _, tc := range testCases {
+		actual, err := MarshalData(tc.input, FormatHCL)
+		if err != nil {
+			t.Errorf("Error marshalling data: %v", err)
+		}
+		if string(actual) != tc.expected {
+			t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+		}
+	}
+}
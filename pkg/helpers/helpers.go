@@ -1,57 +1,316 @@
 package helpers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"io"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/go-yaml/yaml"
 	"github.com/pkg/errors"
 )
 
-func ExtractObjects(input string) []string {
-	re := regexp.MustCompile(`object(?:\.[^\s+\)]+)*`)
-	matches := re.FindAllString(input, -1)
-	objects := []string{}
-	for _, match := range matches {
-		if match != "" {
-			parts := strings.Split(match, ".")
-			lastPart := parts[len(parts)-1]
-			if strings.ContainsAny(lastPart, "(") {
-				match = strings.Join(parts[:len(parts)-1], ".")
-			}
+// Input formats data can be decoded from
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatHCL  = "hcl"
+	FormatXML  = "xml"
+)
+
+// Formats lists the formats that can be given explicitly instead of being detected
+var Formats = []string{FormatJSON, FormatYAML, FormatTOML, FormatHCL, FormatXML}
+
+var formatExtensions = map[string]string{
+	".json":   FormatJSON,
+	".yaml":   FormatYAML,
+	".yml":    FormatYAML,
+	".toml":   FormatTOML,
+	".tf":     FormatHCL,
+	".tfvars": FormatHCL,
+	".hcl":    FormatHCL,
+	".xml":    FormatXML,
+	".csproj": FormatXML,
+	".fsproj": FormatXML,
+	".vbproj": FormatXML,
+	".props":  FormatXML,
+	".nuspec": FormatXML,
+}
+
+// Extensions lists the file extensions of every format in alphabetical order
+func Extensions() []string {
+	extensions := make([]string, 0, len(formatExtensions))
+	for extension := range formatExtensions {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// FormatOf returns the format of a file by its extension, empty for unknown extensions
+func FormatOf(path string) string {
+	return formatExtensions[strings.ToLower(filepath.Ext(path))]
+}
 
+// ValidateFormat checks a format given explicitly, empty meaning detected
+func ValidateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, known := range Formats {
+		if format == known {
+			return nil
 		}
-		objects = append(objects, match)
 	}
-	return objects
+	return errors.Errorf("Invalid format '%s' provided, expected one of: %s", format, strings.Join(Formats, ", "))
 }
 
 func UnmarshalData(data []byte, target interface{}) (string, error) {
-	if err := json.Unmarshal(data, target); err != nil {
+	return UnmarshalDataAs(data, "", target)
+}
+
+// UnmarshalDataAs decodes data in the given format into target, detecting the format when empty
+func UnmarshalDataAs(data []byte, format string, target interface{}) (string, error) {
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(data, target); err != nil {
+			return "", errors.Errorf("Error unmarshalling target: %v", jsonError(data, err))
+		}
+		return FormatJSON, nil
+	case FormatYAML:
 		if err := yaml.Unmarshal(data, target); err != nil {
 			return "", errors.Errorf("Error unmarshalling target: %v", err)
 		}
-		return "yaml", nil
+		return FormatYAML, nil
+	case FormatTOML:
+		if err := toml.Unmarshal(data, target); err != nil {
+			return "", errors.Errorf("Error unmarshalling target: %v", err)
+		}
+		return FormatTOML, nil
+	case FormatHCL, FormatXML:
+		docs, _, err := UnmarshalDocumentsAs(data, format)
+		if err != nil {
+			return "", err
+		}
+		converted, err := json.Marshal(docs[0])
+		if err != nil {
+			return "", errors.Errorf("Error unmarshalling target: %v", err)
+		}
+		if err := json.Unmarshal(converted, target); err != nil {
+			return "", errors.Errorf("Error unmarshalling target: %v", err)
+		}
+		return format, nil
+	case "":
+		detected := detectFormat(data)
+		parsed, err := UnmarshalDataAs(data, detected, target)
+		if err != nil && detected == FormatJSON {
+			if _, yamlErr := UnmarshalDataAs(data, FormatYAML, target); yamlErr == nil {
+				return FormatYAML, nil
+			}
+		}
+		return parsed, err
+	}
+	return "", ValidateFormat(format)
+}
+
+// UnmarshalDocuments decodes the non-empty documents in data, returning them along with the format detected
+func UnmarshalDocuments(data []byte) ([]interface{}, string, error) {
+	return UnmarshalDocumentsAs(data, "")
+}
+
+// UnmarshalDocumentsAs decodes the documents in data in the given format, detecting it when empty
+func UnmarshalDocumentsAs(data []byte, format string) ([]interface{}, string, error) {
+	switch format {
+	case FormatJSON:
+		var jsonDoc interface{}
+		if err := json.Unmarshal(data, &jsonDoc); err != nil {
+			return nil, "", errors.Errorf("Error unmarshalling target: %v", jsonError(data, err))
+		}
+		return []interface{}{jsonDoc}, FormatJSON, nil
+	case FormatYAML:
+		docs, err := yamlDocuments(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return docs, FormatYAML, nil
+	case FormatTOML:
+		docs, err := tomlDocuments(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return docs, FormatTOML, nil
+	case FormatHCL:
+		docs, err := hclDocuments(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return docs, FormatHCL, nil
+	case FormatXML:
+		docs, err := xmlDocuments(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return docs, FormatXML, nil
+	case "":
+		detected := detectFormat(data)
+		docs, parsed, err := UnmarshalDocumentsAs(data, detected)
+		if err != nil && detected == FormatJSON {
+			if docs, _, yamlErr := UnmarshalDocumentsAs(data, FormatYAML); yamlErr == nil {
+				return docs, FormatYAML, nil
+			}
+		}
+		return docs, parsed, err
+	}
+	return nil, "", ValidateFormat(format)
+}
+
+func yamlDocuments(data []byte) ([]interface{}, error) {
+	docs := []interface{}{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Errorf("Error unmarshalling target: %v", err)
+		}
+		if doc == nil {
+			continue
+		}
+		docs = append(docs, stringKeys(doc))
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no documents found")
+	}
+	return docs, nil
+}
+
+// detectFormat tells JSON from YAML, data starting like a JSON object or array being JSON, which falls back to
+// YAML flow style and reports its own syntax errors when both fail
+func detectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') || json.Valid(trimmed) {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// jsonError adds the line and column to JSON errors, which only report an offset
+func jsonError(data []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
 	}
-	return "json", nil
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return errors.Errorf("json: line %d, column %d: %v", line, column, err)
 }
 
-// MarshalData marshals the target into YAML or JSON, returning the formatted bytes, and the format used ('yaml' or 'json')
+func stringKeys(doc interface{}) interface{} {
+	m, ok := doc.(map[interface{}]interface{})
+	if !ok {
+		return doc
+	}
+	converted := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		converted[fmt.Sprint(key)] = value
+	}
+	return converted
+}
+
+// IsScalar tells whether a decoded value is neither a map nor a list
+func IsScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+// LookupString returns the string found under the given keys of nested maps, if any
+func LookupString(obj interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch m := obj.(type) {
+		case map[string]interface{}:
+			obj = m[key]
+		case map[interface{}]interface{}:
+			obj = m[key]
+		default:
+			return ""
+		}
+	}
+	str, _ := obj.(string)
+	return str
+}
+
+// MarshalData marshals the target into the given format, returning the formatted bytes
 func MarshalData(target interface{}, format string) ([]byte, error) {
-	if format == "yaml" {
+	switch format {
+	case FormatYAML:
 		return yaml.Marshal(target)
-	} else if format == "json" {
+	case FormatJSON:
 		jsonStr, err := json.MarshalIndent(target, "", "  ")
 		if err != nil {
 			return nil, err
 		}
 		jsonStrln := fmt.Sprintf("%s\n", jsonStr)
 		return []byte(jsonStrln), nil
-	} else {
-		return nil, errors.Errorf("Invalid format '%s' provided", format)
+	case FormatTOML:
+		return marshalTOML(target)
+	case FormatHCL:
+		return marshalHCL(target)
+	case FormatXML:
+		return marshalXML(target)
+	}
+	return nil, errors.Errorf("Invalid format '%s' provided", format)
+}
+
+// Normalize returns a copy of the value with every map keyed by strings
+func Normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = Normalize(val)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = Normalize(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = Normalize(val)
+		}
+		return l
+	case []map[string]interface{}:
+		// arrays of TOML tables
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = Normalize(val)
+		}
+		return l
 	}
+	return value
 }
 
 func BoolPtr(b bool) *bool {
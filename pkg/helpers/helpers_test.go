@@ -1,65 +1,10 @@
 package helpers
 
 import (
+	"strings"
 	"testing"
 )
 
-func TestExtractObject(t *testing.T) {
-	testCases := []struct {
-		input    string
-		expected []string
-	}{
-		{
-			input:    "object",
-			expected: []string{"object"},
-		},
-		{
-			input:    "object.foo",
-			expected: []string{"object.foo"},
-		},
-		{
-			input:    "object.foo.bar",
-			expected: []string{"object.foo.bar"},
-		},
-		{
-			input:    "size(object.foo.bar.baz) > 0",
-			expected: []string{"object.foo.bar.baz"},
-		},
-		{
-			input:    "has(object.foo.bar.baz[0])",
-			expected: []string{"object.foo.bar.baz[0]"},
-		},
-		{
-			input:    "object.foos.all(foo => foo.bar == 'baz')",
-			expected: []string{"object.foos"},
-		},
-		{
-			input:    "object.foos[1].map(n, n*n)",
-			expected: []string{"object.foos[1]"},
-		},
-		{
-			input:    "object.foos[1].map(n, n*n) && object.bar == 'baz'",
-			expected: []string{"object.foos[1]", "object.bar"},
-		},
-		{
-			input:    "object.foos[1].map(n, n*n) || object.bar == 'baz'",
-			expected: []string{"object.foos[1]", "object.bar"},
-		},
-		{
-			input:    "object.foos[1].map(n, n*n) || object.bar == 'baz' && size(object.foos) > 0",
-			expected: []string{"object.foos[1]", "object.bar", "object.foos"},
-		},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.input, func(t *testing.T) {
-			actual := ExtractObjects(tc.input)
-			if !CompareInterfaces(actual, tc.expected) {
-				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
-			}
-		})
-	}
-}
-
 func TestMarshalData(t *testing.T) {
 	testCases := []struct {
 		input    interface{}
@@ -97,6 +42,21 @@ func TestMarshalData(t *testing.T) {
 ]
 `,
 		},
+		{
+			input:    map[string]interface{}{"package": map[string]interface{}{"name": "celify"}, "bin": []interface{}{map[string]interface{}{"name": "a"}}},
+			format:   "toml",
+			expected: "[[bin]]\nname = \"a\"\n\n[package]\nname = \"celify\"\n",
+		},
+		{
+			input:    []map[string]string{{"foo": "bar"}, {"baz qux": "quux"}},
+			format:   "toml",
+			expected: "[{ foo = \"bar\" }, { \"baz qux\" = \"quux\" }]\n",
+		},
+		{
+			input:    "bar",
+			format:   "toml",
+			expected: "\"bar\"\n",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.format, func(t *testing.T) {
@@ -177,3 +137,109 @@ foo:
 		})
 	}
 }
+
+func TestUnmarshalDocuments(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedFormat string
+		expected       []interface{}
+	}{
+		{
+			input:          `{"foo": "bar"}`,
+			expectedFormat: "json",
+			expected:       []interface{}{map[string]interface{}{"foo": "bar"}},
+		},
+		{
+			input:          `[{"name": "a"}, {"name": "b"}]`,
+			expectedFormat: "json",
+			expected:       []interface{}{[]interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}}},
+		},
+		{
+			input: `- a
+- b
+---
+42
+`,
+			expectedFormat: "yaml",
+			expected:       []interface{}{[]interface{}{"a", "b"}, 42},
+		},
+		{
+			input: `---
+foo: bar
+---
+# only a comment
+---
+baz: qux
+`,
+			expectedFormat: "yaml",
+			expected:       []interface{}{map[string]interface{}{"foo": "bar"}, map[string]interface{}{"baz": "qux"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, format, err := UnmarshalDocuments([]byte(tc.input))
+			if err != nil {
+				t.Errorf("Error unmarshalling documents: %v", err)
+			}
+			if format != tc.expectedFormat {
+				t.Errorf("Expected format '%s', got '%s'", tc.expectedFormat, format)
+			}
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("Expected %d documents, got %d", len(tc.expected), len(actual))
+			}
+			for i := range actual {
+				if !CompareInterfaces(actual[i], tc.expected[i]) {
+					t.Errorf("Expected '%v', got '%v'", tc.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalDocumentsAs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		format         string
+		expectedFormat string
+		expectedError  string
+	}{
+		{name: "json", input: `{"foo": "bar"}`, format: FormatJSON, expectedFormat: FormatJSON},
+		{name: "json as yaml", input: `{"foo": "bar"}`, format: FormatYAML, expectedFormat: FormatYAML},
+		{name: "yaml as json", input: "foo: bar", format: FormatJSON, expectedError: "json: line 1, column 2: invalid character 'o' in literal false (expecting 'a')"},
+		{name: "invalid json", input: "{\n  \"foo\": \"bar\"\n  \"baz\": 1\n}", expectedError: "json: line 3, column 3: invalid character '\"' after object key:value pair"},
+		{name: "yaml flow style", input: "{replicas: 3}", expectedFormat: FormatYAML},
+		{name: "yaml flow style trailing comma", input: "{\n  \"foo\": \"bar\",\n}", expectedFormat: FormatYAML},
+		{name: "detected json", input: "[1, 2]", expectedFormat: FormatJSON},
+		{name: "invalid yaml", input: "foo: bar\n  baz: qux", expectedError: "yaml: line 1: mapping values are not allowed in this context"},
+		{name: "toml", input: "[package]\nname = \"celify\"\n", format: FormatTOML, expectedFormat: FormatTOML},
+		{name: "invalid toml", input: "[package]\nname = celify\n", format: FormatTOML, expectedError: "toml: line 2"},
+		{name: "invalid hcl", input: "a = [1,\n  b = 2\n", format: FormatHCL, expectedError: "hcl: line 2, column 5: Missing item separator"},
+		{name: "unknown format", input: "foo: bar", format: "ini", expectedError: "Invalid format 'ini'"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, format, err := UnmarshalDocumentsAs([]byte(tc.input), tc.format)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing '%s', got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error unmarshalling documents: %v", err)
+			}
+			if format != tc.expectedFormat {
+				t.Errorf("Expected format '%s', got '%s'", tc.expectedFormat, format)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, expected := range map[string]string{"main.tf": FormatHCL, "prod.tfvars": FormatHCL, "Cargo.toml": FormatTOML, "a.json": FormatJSON, "b.YAML": FormatYAML, "c.yml": FormatYAML, "pom.xml": FormatXML, "App.csproj": FormatXML, "d.txt": ""} {
+		if actual := FormatOf(path); actual != expected {
+			t.Errorf("Expected format '%s' for %s, got '%s'", expected, path, actual)
+		}
+	}
+}
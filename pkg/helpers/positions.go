@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"bytes"
+	"celify/pkg/models"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PathKey builds the key of a node from its path, e.g. spec.containers[0]['app.kubernetes.io/name']
+func PathKey(segments []interface{}) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", s)
+		case string:
+			if identifierRegex.MatchString(s) {
+				if b.Len() > 0 {
+					b.WriteString(".")
+				}
+				b.WriteString(s)
+			} else {
+				fmt.Fprintf(&b, "['%s']", strings.ReplaceAll(s, "'", "\\'"))
+			}
+		}
+	}
+	return b.String()
+}
+
+// DocumentPositions returns the position of every node of each document keyed by PathKey, or nil when data can't be parsed
+func DocumentPositions(data []byte, file string) []map[string]models.Position {
+	docs := []map[string]models.Position{}
+	decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yamlv3.Node
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+		positions := map[string]models.Position{}
+		indexNode(doc.Content[0], doc.Content[0], []interface{}{}, file, positions)
+		docs = append(docs, positions)
+	}
+	return docs
+}
+
+// indexNode records the position of node, which is positionNode, the key, for map values
+func indexNode(node, positionNode *yamlv3.Node, path []interface{}, file string, positions map[string]models.Position) {
+	positions[PathKey(path)] = models.Position{File: file, Line: positionNode.Line, Column: positionNode.Column}
+	switch node.Kind {
+	case yamlv3.AliasNode:
+		indexNode(node.Alias, positionNode, path, file, positions)
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			indexNode(value, key, appendSegment(path, key.Value), file, positions)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			indexNode(item, item, appendSegment(path, i), file, positions)
+		}
+	}
+}
+
+func appendSegment(path []interface{}, segment interface{}) []interface{} {
+	newPath := make([]interface{}, len(path), len(path)+1)
+	copy(newPath, path)
+	return append(newPath, segment)
+}
+
+// SubPositions returns the positions of the nodes under the node at prefix, keyed by their path relative to it
+func SubPositions(positions map[string]models.Position, prefix string) map[string]models.Position {
+	if positions == nil {
+		return nil
+	}
+	sub := map[string]models.Position{}
+	for key, position := range positions {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		switch {
+		case rest == "":
+			sub[""] = position
+		case strings.HasPrefix(rest, "."):
+			sub[rest[1:]] = position
+		case strings.HasPrefix(rest, "["):
+			sub[rest] = position
+		}
+	}
+	return sub
+}
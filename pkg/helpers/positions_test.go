@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"celify/pkg/models"
+	"reflect"
+	"testing"
+)
+
+func TestPathKey(t *testing.T) {
+	testCases := []struct {
+		segments []interface{}
+		expected string
+	}{
+		{segments: []interface{}{}, expected: ""},
+		{segments: []interface{}{"spec", "containers", 0, "name"}, expected: "spec.containers[0].name"},
+		{segments: []interface{}{"metadata", "labels", "app.kubernetes.io/name"}, expected: "metadata.labels['app.kubernetes.io/name']"},
+		{segments: []interface{}{0, "name"}, expected: "[0].name"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if actual := PathKey(tc.segments); actual != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDocumentPositions(t *testing.T) {
+	input := `---
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: web
+---
+---
+spec:
+  containers:
+  - name: app
+    image: nginx
+`
+	positions := DocumentPositions([]byte(input), "deployment.yaml")
+	if len(positions) != 2 {
+		t.Fatalf("Expected positions for 2 documents, got %d", len(positions))
+	}
+	testCases := []struct {
+		document int
+		path     string
+		expected models.Position
+	}{
+		{document: 0, path: "", expected: models.Position{File: "deployment.yaml", Line: 2, Column: 1}},
+		{document: 0, path: "metadata.labels['app.kubernetes.io/name']", expected: models.Position{File: "deployment.yaml", Line: 5, Column: 5}},
+		{document: 1, path: "spec.containers", expected: models.Position{File: "deployment.yaml", Line: 9, Column: 3}},
+		{document: 1, path: "spec.containers[0]", expected: models.Position{File: "deployment.yaml", Line: 10, Column: 5}},
+		{document: 1, path: "spec.containers[0].image", expected: models.Position{File: "deployment.yaml", Line: 11, Column: 5}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			actual, ok := positions[tc.document][tc.path]
+			if !ok {
+				t.Fatalf("Expected a position for '%s'", tc.path)
+			}
+			if actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSubPositions(t *testing.T) {
+	positions := map[string]models.Position{
+		"":             {Line: 1, Column: 1},
+		"[0]":          {Line: 1, Column: 3},
+		"[0].name":     {Line: 1, Column: 3},
+		"[0].ports":    {Line: 2, Column: 3},
+		"[0].ports[0]": {Line: 3, Column: 5},
+		"[1]":          {Line: 4, Column: 3},
+		"[10]":         {Line: 5, Column: 3},
+	}
+	expected := map[string]models.Position{
+		"":         {Line: 1, Column: 3},
+		"name":     {Line: 1, Column: 3},
+		"ports":    {Line: 2, Column: 3},
+		"ports[0]": {Line: 3, Column: 5},
+	}
+	if actual := SubPositions(positions, "[0]"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
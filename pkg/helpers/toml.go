@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+func tomlDocuments(data []byte) ([]interface{}, error) {
+	doc := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Errorf("Error unmarshalling target: %v", err)
+	}
+	return []interface{}{Normalize(doc)}, nil
+}
+
+// marshalTOML renders tables as TOML documents and other values inline, as they can't be a document root
+func marshalTOML(target interface{}) ([]byte, error) {
+	target = Normalize(target)
+	if reflect.ValueOf(target).Kind() != reflect.Map {
+		value, err := tomlInline(target)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(value + "\n"), nil
+	}
+	var b bytes.Buffer
+	encoder := toml.NewEncoder(&b)
+	encoder.Indent = ""
+	if err := encoder.Encode(target); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// tomlInline renders a value as an inline TOML value, null being a comment
+func tomlInline(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "# null", nil
+	case string:
+		var b bytes.Buffer
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rendered, err := tomlInline(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, rendered)
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		entries := make([]string, 0, len(keys))
+		for _, key := range keys {
+			element := rv.MapIndex(key).Interface()
+			if element == nil {
+				continue
+			}
+			rendered, err := tomlInline(element)
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, fmt.Sprintf("%s = %s", tomlKey(fmt.Sprint(key.Interface())), rendered))
+		}
+		return "{ " + strings.Join(entries, ", ") + " }", nil
+	}
+	return "", errors.Errorf("unable to render %T as TOML", value)
+}
+
+func tomlKey(key string) string {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			rendered, _ := tomlInline(key)
+			return rendered
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
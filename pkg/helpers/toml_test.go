@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOMLDocuments(t *testing.T) {
+	source := `title = "celify"
+released = 2023-10-01T12:30:00Z
+
+[package]
+name = "celify"
+keywords = ["cel", "validation"]
+
+[[bin]]
+name = "a"
+
+[[bin]]
+name = "b"
+
+[[bin.features]]
+name = "tls"
+`
+	docs, format, err := UnmarshalDocumentsAs([]byte(source), FormatTOML)
+	if err != nil {
+		t.Fatalf("Error unmarshalling documents: %v", err)
+	}
+	if format != FormatTOML {
+		t.Errorf("Expected format '%s', got '%s'", FormatTOML, format)
+	}
+	expected := map[string]interface{}{
+		"title":    "celify",
+		"released": time.Date(2023, 10, 1, 12, 30, 0, 0, time.UTC),
+		"package":  map[string]interface{}{"name": "celify", "keywords": []interface{}{"cel", "validation"}},
+		"bin": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b", "features": []interface{}{map[string]interface{}{"name": "tls"}}},
+		},
+	}
+	if len(docs) != 1 || !reflect.DeepEqual(docs[0], expected) {
+		t.Errorf("Expected %v, got %v", expected, docs)
+	}
+
+	_, _, err = UnmarshalDocumentsAs([]byte("[package]\nname = = 1\n"), FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error with its line, got %v", err)
+	}
+}
+
+func TestMarshalTOML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name: "nested array tables",
+			input: map[string]interface{}{"bin": []interface{}{
+				map[string]interface{}{"name": "b", "features": []interface{}{map[string]interface{}{"name": "tls"}}},
+			}},
+			expected: "[[bin]]\nname = \"b\"\n\n[[bin.features]]\nname = \"tls\"\n",
+		},
+		{
+			name:     "datetime",
+			input:    map[string]interface{}{"released": time.Date(2023, 10, 1, 12, 30, 0, 0, time.UTC)},
+			expected: "released = 2023-10-01T12:30:00Z\n",
+		},
+		{
+			name:     "list root",
+			input:    []interface{}{int64(1), "two", nil},
+			expected: "[1, \"two\", # null]\n",
+		},
+		{
+			name:     "nil root",
+			input:    nil,
+			expected: "# null\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := MarshalData(tc.input, FormatTOML)
+			if err != nil {
+				t.Fatalf("Error marshalling data: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTOMLInline(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{name: "nil", input: nil, expected: "# null"},
+		{name: "string", input: `a "quoted" <tag> & more`, expected: `"a \"quoted\" <tag> & more"`},
+		{name: "bool", input: true, expected: "true"},
+		{name: "int", input: int64(42), expected: "42"},
+		{name: "float", input: 1.5, expected: "1.5"},
+		{name: "datetime", input: time.Date(2023, 10, 1, 12, 30, 0, 500, time.UTC), expected: "2023-10-01T12:30:00.0000005Z"},
+		{name: "list", input: []interface{}{"a", []interface{}{int64(1)}}, expected: `["a", [1]]`},
+		{name: "table without nil values", input: map[string]interface{}{"b": nil, "a": "x", "c d": int64(1)}, expected: `{ a = "x", "c d" = 1 }`},
+		{name: "nested tables", input: map[string]interface{}{"a": map[string]interface{}{"b": true}}, expected: "{ a = { b = true } }"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tomlInline(tc.input)
+			if err != nil {
+				t.Fatalf("Error rendering value: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := tomlInline(func() {}); err == nil {
+		t.Errorf("Expected an error for a function, got none")
+	}
+}
+
+func TestTOMLKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"name":                   "name",
+		"bare-key_2":             "bare-key_2",
+		"":                       `""`,
+		"with space":             `"with space"`,
+		"app.kubernetes.io/name": `"app.kubernetes.io/name"`,
+		"ключ":                   `"ключ"`,
+		`quote"d`:                `"quote\"d"`,
+	} {
+		if actual := tomlKey(key); actual != expected {
+			t.Errorf("Expected key '%s' to be rendered as %s, got %s", key, expected, actual)
+		}
+	}
+}
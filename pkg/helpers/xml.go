@@ -0,0 +1,276 @@
+package helpers
+
+import (
+	"bytes"
+	"celify/pkg/models"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// XMLAttributePrefix prefixes the keys of element attributes, e.g. @version
+	XMLAttributePrefix = "@"
+	// XMLTextKey holds the text of elements that also have attributes or children
+	XMLTextKey = "#text"
+	xmlElement = "element"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// xmlConverter converts an XML document into the object, recording positions when positions isn't nil
+type xmlConverter struct {
+	src       []byte
+	file      string
+	decoder   *xml.Decoder
+	positions map[string]models.Position
+}
+
+func xmlDocuments(data []byte) ([]interface{}, error) {
+	doc, err := parseXML(data, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{doc}, nil
+}
+
+// XMLPositions returns the position of every node of an XML file keyed by PathKey
+func XMLPositions(data []byte, file string) []map[string]models.Position {
+	positions := map[string]models.Position{}
+	if _, err := parseXML(data, file, positions); err != nil {
+		return nil
+	}
+	return []map[string]models.Position{positions}
+}
+
+func parseXML(data []byte, file string, positions map[string]models.Position) (map[string]interface{}, error) {
+	src := bytes.TrimPrefix(data, utf8BOM)
+	c := xmlConverter{src: src, file: file, decoder: xml.NewDecoder(bytes.NewReader(src)), positions: positions}
+	for {
+		token, err := c.decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("Error unmarshalling target: xml: no root element")
+		}
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			c.record([]interface{}{}, c.startOffset())
+			path := []interface{}{start.Name.Local}
+			c.record(path, c.startOffset())
+			root, err := c.element(start, path)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: root}, nil
+		}
+	}
+}
+
+// element converts an element with only text into the text, else into a map of attributes, child lists and text
+func (c xmlConverter) element(start xml.StartElement, path []interface{}) (interface{}, error) {
+	obj := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		key := XMLAttributePrefix + attributeName(attr.Name)
+		c.record(appendSegment(path, key), c.startOffset())
+		obj[key] = attr.Value
+	}
+	var text strings.Builder
+	hasChildren := false
+	for {
+		token, err := c.decoder.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			hasChildren = true
+			offset := c.startOffset()
+			name := t.Name.Local
+			list, _ := obj[name].([]interface{})
+			c.recordFirst(appendSegment(path, name), offset)
+			childPath := appendSegment(appendSegment(path, name), len(list))
+			c.record(childPath, offset)
+			child, err := c.element(t, childPath)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = append(list, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			trimmed := strings.TrimSpace(text.String())
+			if len(obj) == 0 && !hasChildren {
+				return trimmed, nil
+			}
+			if trimmed != "" {
+				obj[XMLTextKey] = trimmed
+			}
+			return obj, nil
+		}
+	}
+}
+
+// attributeName drops namespaces, except for declarations like xmlns:xsi
+func attributeName(name xml.Name) string {
+	if name.Space == "xmlns" {
+		return "xmlns:" + name.Local
+	}
+	return name.Local
+}
+
+// startOffset returns where the last token starts, as the decoder only gives its end
+func (c xmlConverter) startOffset() int {
+	end := int(c.decoder.InputOffset())
+	if end > len(c.src) {
+		end = len(c.src)
+	}
+	if start := bytes.LastIndexByte(c.src[:end], '<'); start >= 0 {
+		return start
+	}
+	return end
+}
+
+func (c xmlConverter) record(path []interface{}, offset int) {
+	if c.positions == nil {
+		return
+	}
+	before := c.src[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	c.positions[PathKey(path)] = models.Position{File: c.file, Line: line, Column: column}
+}
+
+func (c xmlConverter) recordFirst(path []interface{}, offset int) {
+	if c.positions == nil {
+		return
+	}
+	if _, ok := c.positions[PathKey(path)]; !ok {
+		c.record(path, offset)
+	}
+}
+
+func xmlError(err error) error {
+	if syntaxErr, ok := err.(*xml.SyntaxError); ok {
+		return errors.Errorf("Error unmarshalling target: xml: line %d: %s", syntaxErr.Line, syntaxErr.Msg)
+	}
+	return errors.Errorf("Error unmarshalling target: xml: %v", err)
+}
+
+// MarshalXMLElement renders a value as the XML element it was converted from
+func MarshalXMLElement(name string, target interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeXMLElement(&b, name, Normalize(target), ""); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// marshalXML renders maps of elements as those elements and element content, lacking a name, as an <element>
+func marshalXML(target interface{}) ([]byte, error) {
+	target = Normalize(target)
+	obj, isMap := target.(map[string]interface{})
+	if isMap && len(obj) > 0 && !hasXMLContent(obj) {
+		var b bytes.Buffer
+		for _, key := range sortedXMLKeys(obj) {
+			if err := writeXMLElement(&b, key, obj[key], ""); err != nil {
+				return nil, err
+			}
+		}
+		return b.Bytes(), nil
+	}
+	if !isMap && IsScalar(target) {
+		return []byte(xmlText(target) + "\n"), nil
+	}
+	return MarshalXMLElement(xmlElement, target)
+}
+
+func writeXMLElement(b *bytes.Buffer, name string, value interface{}, indent string) error {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := writeXMLElement(b, name, item, indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		fmt.Fprintf(b, "%s<%s", indent, name)
+		children := []string{}
+		for _, key := range sortedXMLKeys(v) {
+			if strings.HasPrefix(key, XMLAttributePrefix) {
+				fmt.Fprintf(b, ` %s="%s"`, strings.TrimPrefix(key, XMLAttributePrefix), xmlText(v[key]))
+			} else if key != XMLTextKey {
+				children = append(children, key)
+			}
+		}
+		text, hasText := v[XMLTextKey]
+		if len(children) == 0 && !hasText {
+			b.WriteString("/>\n")
+			return nil
+		}
+		if len(children) == 0 {
+			fmt.Fprintf(b, ">%s</%s>\n", xmlText(text), name)
+			return nil
+		}
+		b.WriteString(">\n")
+		if hasText {
+			fmt.Fprintf(b, "%s  %s\n", indent, xmlText(text))
+		}
+		for _, key := range children {
+			if err := writeXMLElement(b, key, v[key], indent+"  "); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "%s</%s>\n", indent, name)
+		return nil
+	}
+	if !IsScalar(value) {
+		return errors.Errorf("unable to render %T as XML", value)
+	}
+	if value == nil {
+		fmt.Fprintf(b, "%s<%s/>\n", indent, name)
+		return nil
+	}
+	fmt.Fprintf(b, "%s<%s>%s</%s>\n", indent, name, xmlText(value), name)
+	return nil
+}
+
+func hasXMLContent(obj map[string]interface{}) bool {
+	for key := range obj {
+		if strings.HasPrefix(key, XMLAttributePrefix) || key == XMLTextKey {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedXMLKeys sorts keys alphabetically, attributes first
+func sortedXMLKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iAttr, jAttr := strings.HasPrefix(keys[i], XMLAttributePrefix), strings.HasPrefix(keys[j], XMLAttributePrefix)
+		if iAttr != jAttr {
+			return iAttr
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func xmlText(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(fmt.Sprint(value)))
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"celify/pkg/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const xmlSource = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <modelVersion>4.0.0</modelVersion>
+  <dependencies>
+    <dependency scope="test">
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+    </dependency>
+    <dependency>
+      <artifactId>spring-core</artifactId>
+      <optional/>
+    </dependency>
+  </dependencies>
+  <description>
+    Mixed <b>content</b>
+  </description>
+</project>
+`
+
+func TestXMLDocuments(t *testing.T) {
+	docs, format, err := UnmarshalDocumentsAs([]byte("\xef\xbb\xbf"+xmlSource), FormatXML)
+	if err != nil {
+		t.Fatalf("Error unmarshalling documents: %v", err)
+	}
+	if format != FormatXML {
+		t.Errorf("Expected format '%s', got '%s'", FormatXML, format)
+	}
+	expected := map[string]interface{}{
+		"project": map[string]interface{}{
+			"@xmlns":       "http://maven.apache.org/POM/4.0.0",
+			"@xmlns:xsi":   "http://www.w3.org/2001/XMLSchema-instance",
+			"modelVersion": []interface{}{"4.0.0"},
+			"dependencies": []interface{}{
+				map[string]interface{}{
+					"dependency": []interface{}{
+						map[string]interface{}{
+							"@scope":     "test",
+							"artifactId": []interface{}{"junit"},
+							"version":    []interface{}{"4.13.2"},
+						},
+						map[string]interface{}{
+							"artifactId": []interface{}{"spring-core"},
+							"optional":   []interface{}{""},
+						},
+					},
+				},
+			},
+			"description": []interface{}{
+				map[string]interface{}{"#text": "Mixed", "b": []interface{}{"content"}},
+			},
+		},
+	}
+	if len(docs) != 1 || !reflect.DeepEqual(docs[0], expected) {
+		t.Errorf("Expected %v, got %v", expected, docs)
+	}
+}
+
+func TestXMLDocumentsErrors(t *testing.T) {
+	for input, expected := range map[string]string{
+		"<project>\n  <version>1</project>": "xml: line 2: element <version> closed by </project>",
+		"<!-- empty -->":                    "xml: no root element",
+	} {
+		_, _, err := UnmarshalDocumentsAs([]byte(input), FormatXML)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing '%s', got %v", expected, err)
+		}
+	}
+}
+
+func TestXMLPositions(t *testing.T) {
+	positions := XMLPositions([]byte(xmlSource), "pom.xml")
+	if len(positions) != 1 {
+		t.Fatalf("Expected positions for 1 document, got %d", len(positions))
+	}
+	expected := map[string]models.Position{
+		"project":                                             {File: "pom.xml", Line: 2, Column: 1},
+		"project['@xmlns:xsi']":                               {File: "pom.xml", Line: 2, Column: 1},
+		"project.modelVersion[0]":                             {File: "pom.xml", Line: 3, Column: 3},
+		"project.dependencies[0].dependency":                  {File: "pom.xml", Line: 5, Column: 5},
+		"project.dependencies[0].dependency[1]":               {File: "pom.xml", Line: 9, Column: 5},
+		"project.dependencies[0].dependency[1].optional[0]":   {File: "pom.xml", Line: 11, Column: 7},
+		"project.dependencies[0].dependency[0]['@scope']":     {File: "pom.xml", Line: 5, Column: 5},
+		"project.dependencies[0].dependency[0].artifactId[0]": {File: "pom.xml", Line: 6, Column: 7},
+	}
+	for key, position := range expected {
+		if actual, ok := positions[0][key]; !ok || actual != position {
+			t.Errorf("Expected %s at %v, got %v", key, position, actual)
+		}
+	}
+}
+
+func TestMarshalXML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "document",
+			input:    map[string]interface{}{"project": map[string]interface{}{"@xmlns": "x", "modelVersion": []interface{}{"4.0.0"}}},
+			expected: "<project xmlns=\"x\">\n  <modelVersion>4.0.0</modelVersion>\n</project>\n",
+		},
+		{
+			name:     "element content",
+			input:    map[string]interface{}{"@scope": "test", "#text": "a & b", "optional": []interface{}{""}},
+			expected: "<element scope=\"test\">\n  a &amp; b\n  <optional></optional>\n</element>\n",
+		},
+		{
+			name:     "text",
+			input:    "4.0.0",
+			expected: "4.0.0\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := MarshalData(tc.input, FormatXML)
+			if err != nil {
+				t.Errorf("Error marshalling data: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMarshalXMLElement(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{"@scope": "test", "artifactId": []interface{}{"junit"}},
+		map[string]interface{}{"artifactId": []interface{}{"spring-core"}},
+	}
+	expected := "<dependency scope=\"test\">\n  <artifactId>junit</artifactId>\n</dependency>\n<dependency>\n  <artifactId>spring-core</artifactId>\n</dependency>\n"
+	actual, err := MarshalXMLElement("dependency", input)
+	if err != nil {
+		t.Fatalf("Error marshalling element: %v", err)
+	}
+	if string(actual) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+}
@@ -1,26 +1,111 @@
 package models
 
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Severities lists the supported rule severities, from the most to the least severe
+var Severities = []string{SeverityError, SeverityWarning, SeverityInfo}
+
 type ValidationRule struct {
-	Expression        string `yaml:"expression"`
-	MessageExpression string `yaml:"messageExpression"`
+	// ID identifies the rule in reports, it must be unique within a validations file
+	ID               string   `yaml:"id"`
+	Name             string   `yaml:"name"`
+	Description      string   `yaml:"description"`
+	DocumentationURL string   `yaml:"documentationURL"`
+	Tags             []string `yaml:"tags"`
+	// Severity is one of Severities, rules without one being errors
+	Severity string `yaml:"severity"`
+	// MatchConditions must all be true for the rule to be evaluated, otherwise it's skipped
+	MatchConditions   []MatchCondition `yaml:"matchConditions"`
+	Expression        string           `yaml:"expression"`
+	MessageExpression string           `yaml:"messageExpression"`
+}
+
+// EffectiveSeverity returns the severity of the rule, defaulting to error
+func (r ValidationRule) EffectiveSeverity() string {
+	if r.Severity == "" {
+		return SeverityError
+	}
+	return r.Severity
 }
 
 type ValidationConfig struct {
-	Validations []ValidationRule `yaml:"validations"`
+	// Variables are evaluated lazily and available to every expression as variables.<name>
+	Variables []Variable `yaml:"variables"`
+	// MatchConditions are checked before the match conditions of every rule
+	MatchConditions []MatchCondition `yaml:"matchConditions"`
+	Validations     []ValidationRule `yaml:"validations"`
+	// Params are the default params, used unless others are given
+	Params interface{} `yaml:"params"`
+}
+
+// Variable is a named expression shared by rules, which can reference the variables declared before it
+type Variable struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+}
+
+// MatchCondition is an expression scoping rules to the targets it's true for, e.g. object.kind == 'Deployment'
+type MatchCondition struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
 }
 
 type TargetData struct {
-	Data   map[string]interface{}
-	Format string
+	Data     map[string]interface{}
+	Format   string
+	Source   string
+	Document int
+	Kind     string
+	Name     string
+	// Positions maps the path of every node in the document, as built by helpers.PathKey, to its position in the source
+	Positions map[string]Position
+}
+
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+type TargetResult struct {
+	Target  *TargetData
+	Results []EvaluationResult
 }
 
 type EvaluationResult struct {
+	// Rule is the validation the result belongs to
+	Rule             ValidationRule
 	Expression       string
 	EvaluatedObjects []EvaluatedObject
-	ValidationError  error
+	// Message holds the result of the message expression, or a generic message, when the validation failed,
+	// or the match condition that wasn't met when it was skipped
+	Message string
+	// Skipped is set when the validation wasn't evaluated as its match conditions weren't met
+	Skipped         bool
+	ValidationError error
+	// Explanation is the evaluation trace of a failed validation, only set when explaining
+	Explanation *ExplainNode
+}
+
+// ExplainNode is a sub-expression of a validation along with the value it evaluated to
+type ExplainNode struct {
+	Expression string
+	Value      interface{}
+	// Evaluated is false when the sub-expression wasn't reached during the evaluation
+	Evaluated bool
+	// Culprit marks the sub-expressions that made the validation fail
+	Culprit  bool
+	Children []ExplainNode
 }
 
 type EvaluatedObject struct {
 	Expression string
 	Object     interface{}
+	// Name is the name of an element reported on its own, like a container failing an all() macro
+	Name     string
+	Position *Position
 }
@@ -0,0 +1,69 @@
+package printer
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// printExplanation prints the evaluation trace as a tree, the clauses that made the validation fail in red
+func printExplanation(w io.Writer, node *models.ExplainNode) {
+	fmt.Fprintf(w, "%s %s\n", getErrorStr(), color.New(color.Underline).Sprint("explain:"))
+	for _, line := range explanationLines(node, 1) {
+		lineColor := color.New(color.FgGreen)
+		if line.node.Culprit {
+			lineColor = color.New(color.FgRed).Add(color.Bold)
+		} else if !line.node.Evaluated {
+			lineColor = color.New(color.Faint)
+		}
+		fmt.Fprintf(w, "%s %s%s\n", getErrorStr(), line.indent, lineColor.Sprint(line.text))
+	}
+	fmt.Fprintf(w, "%s\n", getErrorStr())
+}
+
+type explanationLine struct {
+	indent string
+	text   string
+	node   *models.ExplainNode
+}
+
+func explanationLines(node *models.ExplainNode, depth int) []explanationLine {
+	marker := " "
+	if node.Culprit {
+		marker = "✗"
+	}
+	lines := []explanationLine{{
+		indent: strings.Repeat("  ", depth),
+		text:   fmt.Sprintf("%s %s => %s", marker, node.Expression, formatExplainValue(node)),
+		node:   node,
+	}}
+	for i := range node.Children {
+		lines = append(lines, explanationLines(&node.Children[i], depth+1)...)
+	}
+	return lines
+}
+
+// FormatExplanation renders the evaluation trace as an indented plain text tree
+func FormatExplanation(node *models.ExplainNode) string {
+	var b strings.Builder
+	for _, line := range explanationLines(node, 0) {
+		fmt.Fprintf(&b, "%s%s\n", line.indent, line.text)
+	}
+	return b.String()
+}
+
+func formatExplainValue(node *models.ExplainNode) string {
+	if !node.Evaluated {
+		return "not evaluated"
+	}
+	value, err := json.Marshal(helpers.Normalize(node.Value))
+	if err != nil {
+		return fmt.Sprint(node.Value)
+	}
+	return string(value)
+}
@@ -0,0 +1,143 @@
+package printer
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"encoding/json"
+)
+
+// JSONSchemaVersion is bumped whenever a breaking change is made to the JSON output
+const JSONSchemaVersion = "1"
+
+type jsonReport struct {
+	Version string       `json:"version"`
+	Summary Summary      `json:"summary"`
+	Targets []jsonTarget `json:"targets"`
+}
+
+type jsonTarget struct {
+	Source   string       `json:"source"`
+	Document int          `json:"document"`
+	Kind     string       `json:"kind,omitempty"`
+	Name     string       `json:"name,omitempty"`
+	Format   string       `json:"format"`
+	Passed   bool         `json:"passed"`
+	Results  []jsonResult `json:"results"`
+}
+
+type jsonResult struct {
+	ID               string       `json:"id,omitempty"`
+	Name             string       `json:"name,omitempty"`
+	Description      string       `json:"description,omitempty"`
+	DocumentationURL string       `json:"documentationURL,omitempty"`
+	Tags             []string     `json:"tags,omitempty"`
+	Severity         string       `json:"severity"`
+	Expression       string       `json:"expression"`
+	Passed           bool         `json:"passed"`
+	Skipped          bool         `json:"skipped,omitempty"`
+	Message          string       `json:"message,omitempty"`
+	Error            string       `json:"error,omitempty"`
+	EvaluatedObjects []jsonObject `json:"evaluatedObjects,omitempty"`
+	Explanation      *jsonExplain `json:"explanation,omitempty"`
+}
+
+type jsonExplain struct {
+	Expression string        `json:"expression"`
+	Value      interface{}   `json:"value"`
+	Evaluated  bool          `json:"evaluated"`
+	Culprit    bool          `json:"culprit"`
+	Children   []jsonExplain `json:"children,omitempty"`
+}
+
+type jsonObject struct {
+	Expression string        `json:"expression"`
+	Name       string        `json:"name,omitempty"`
+	Value      interface{}   `json:"value"`
+	Location   *jsonLocation `json:"location,omitempty"`
+}
+
+type jsonLocation struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (p *Printer) printJSON(targetResults []models.TargetResult, supressObjects bool) error {
+	report := jsonReport{
+		Version: JSONSchemaVersion,
+		Summary: Summarize(targetResults),
+		Targets: []jsonTarget{},
+	}
+	for _, targetResult := range targetResults {
+		target := jsonTarget{
+			Source:   targetResult.Target.Source,
+			Document: targetResult.Target.Document,
+			Kind:     targetResult.Target.Kind,
+			Name:     targetResult.Target.Name,
+			Format:   targetResult.Target.Format,
+			Passed:   true,
+			Results:  []jsonResult{},
+		}
+		for _, result := range targetResult.Results {
+			jResult := jsonResult{
+				ID:               result.Rule.ID,
+				Name:             result.Rule.Name,
+				Description:      result.Rule.Description,
+				DocumentationURL: result.Rule.DocumentationURL,
+				Tags:             result.Rule.Tags,
+				Severity:         result.Rule.EffectiveSeverity(),
+				Expression:       result.Expression,
+				Passed:           result.ValidationError == nil,
+			}
+			if result.Skipped {
+				jResult.Skipped = true
+				jResult.Message = result.Message
+			}
+			if result.ValidationError != nil {
+				target.Passed = false
+				jResult.Message = result.Message
+				jResult.Error = result.ValidationError.Error()
+				if !supressObjects {
+					for _, obj := range result.EvaluatedObjects {
+						jObject := jsonObject{
+							Expression: obj.Expression,
+							Name:       obj.Name,
+							Value:      helpers.Normalize(obj.Object),
+						}
+						if obj.Position != nil {
+							jObject.Location = &jsonLocation{
+								File:   obj.Position.File,
+								Line:   obj.Position.Line,
+								Column: obj.Position.Column,
+							}
+						}
+						jResult.EvaluatedObjects = append(jResult.EvaluatedObjects, jObject)
+					}
+				}
+			}
+			if result.Explanation != nil {
+				explanation := toJSONExplain(*result.Explanation)
+				jResult.Explanation = &explanation
+			}
+			target.Results = append(target.Results, jResult)
+		}
+		report.Targets = append(report.Targets, target)
+	}
+	encoder := json.NewEncoder(p.Writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+func toJSONExplain(node models.ExplainNode) jsonExplain {
+	jNode := jsonExplain{
+		Expression: node.Expression,
+		Value:      helpers.Normalize(node.Value),
+		Evaluated:  node.Evaluated,
+		Culprit:    node.Culprit,
+	}
+	for _, child := range node.Children {
+		jNode.Children = append(jNode.Children, toJSONExplain(child))
+	}
+	return jNode
+}
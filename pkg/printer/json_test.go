@@ -0,0 +1,158 @@
+package printer
+
+import (
+	"bytes"
+	"celify/pkg/models"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrintJSON(t *testing.T) {
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{
+				Format:   "yaml",
+				Source:   "deployment.yaml",
+				Document: 1,
+				Kind:     "Deployment",
+				Name:     "my-app",
+			},
+			Results: []models.EvaluationResult{
+				{
+					Expression: "object.spec.replicas > 1",
+				},
+				{
+					Rule: models.ValidationRule{
+						ID:               "team-label",
+						Name:             "Team label",
+						DocumentationURL: "https://example.com/team-label",
+						Tags:             []string{"labels"},
+						Severity:         models.SeverityWarning,
+					},
+					Expression: "object.metadata.labels.team == 'a'",
+					EvaluatedObjects: []models.EvaluatedObject{
+						{
+							Expression: "object.metadata.labels",
+							Object:     map[interface{}]interface{}{"team": "b"},
+						},
+					},
+					Message:         "team must be a",
+					ValidationError: errors.New("message: team must be a"),
+				},
+			},
+		},
+	}
+	expected := map[string]interface{}{
+		"version": "1",
+		"summary": map[string]interface{}{"targets": 1.0, "documents": 1.0, "passed": 0.0, "failed": 1.0},
+		"targets": []interface{}{
+			map[string]interface{}{
+				"source":   "deployment.yaml",
+				"document": 1.0,
+				"kind":     "Deployment",
+				"name":     "my-app",
+				"format":   "yaml",
+				"passed":   false,
+				"results": []interface{}{
+					map[string]interface{}{
+						"expression": "object.spec.replicas > 1",
+						"severity":   "error",
+						"passed":     true,
+					},
+					map[string]interface{}{
+						"id":               "team-label",
+						"name":             "Team label",
+						"documentationURL": "https://example.com/team-label",
+						"tags":             []interface{}{"labels"},
+						"severity":         "warning",
+						"expression":       "object.metadata.labels.team == 'a'",
+						"passed":           false,
+						"message":          "team must be a",
+						"error":            "message: team must be a",
+						"evaluatedObjects": []interface{}{
+							map[string]interface{}{
+								"expression": "object.metadata.labels",
+								"value":      map[string]interface{}{"team": "b"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	p, err := NewPrinter(OutputJSON, &b)
+	if err != nil {
+		t.Fatalf("Error creating printer: %v", err)
+	}
+	if err := p.PrintResults(targetResults, false); err != nil {
+		t.Fatalf("Error printing results: %v", err)
+	}
+	var actual map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &actual); err != nil {
+		t.Fatalf("Error unmarshalling output: %v\n%s", err, b.String())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestPrintUnescapedHTML(t *testing.T) {
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{Format: "yaml", Source: "deployment.yaml"},
+			Results: []models.EvaluationResult{
+				{
+					Expression:      "object.replicas > 1 && object.replicas < 5",
+					Message:         "replicas must be > 1 && < 5",
+					ValidationError: errors.New("message: replicas must be > 1 && < 5"),
+				},
+			},
+		},
+	}
+	for _, output := range []string{OutputJSON, OutputSARIF} {
+		t.Run(output, func(t *testing.T) {
+			var b bytes.Buffer
+			p, err := NewPrinter(output, &b)
+			if err != nil {
+				t.Fatalf("Error creating printer: %v", err)
+			}
+			if err := p.PrintResults(targetResults, false); err != nil {
+				t.Fatalf("Error printing results: %v", err)
+			}
+			if !strings.Contains(b.String(), "object.replicas > 1 && object.replicas < 5") || strings.Contains(b.String(), `\u0026`) {
+				t.Errorf("Expected expressions without escaped HTML, got %s", b.String())
+			}
+		})
+	}
+}
+
+func TestNewPrinterRejectsUnknownOutput(t *testing.T) {
+	if _, err := NewPrinter("xml", &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected error, got none")
+	}
+}
+
+func TestRuleLabel(t *testing.T) {
+	testCases := []struct {
+		rule     models.ValidationRule
+		expected string
+	}{
+		{rule: models.ValidationRule{}, expected: "object.replicas > 1"},
+		{rule: models.ValidationRule{ID: "replicas"}, expected: "replicas"},
+		{rule: models.ValidationRule{Name: "Minimum replicas"}, expected: "Minimum replicas"},
+		{rule: models.ValidationRule{ID: "replicas", Name: "Minimum replicas"}, expected: "Minimum replicas (replicas)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			actual := RuleLabel(models.EvaluationResult{Rule: tc.rule, Expression: "object.replicas > 1"})
+			if actual != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
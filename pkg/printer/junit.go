@@ -0,0 +1,135 @@
+package printer
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+func (p *Printer) printJUnit(targetResults []models.TargetResult, supressObjects bool) error {
+	report := junitTestSuites{Name: "celify"}
+	for _, targetResult := range targetResults {
+		suite := junitTestSuite{Name: TargetLabel(targetResult.Target)}
+		className := targetResult.Target.Source
+		if className == "" {
+			className = "inline"
+		}
+		for _, result := range targetResult.Results {
+			testCase := junitTestCase{
+				Name:      RuleLabel(result),
+				ClassName: className,
+			}
+			if result.ValidationError != nil {
+				testCase.Failure = &junitFailure{
+					Message: result.Message,
+					Type:    junitFailureType(result.Rule.EffectiveSeverity()),
+					Body:    failureBody(result, targetResult.Target.Format, supressObjects),
+				}
+				suite.Failures++
+			}
+			if result.Skipped {
+				testCase.Skipped = &junitSkipped{Message: result.Message}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+	if _, err := io.WriteString(p.Writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(p.Writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(p.Writer)
+	return err
+}
+
+// junitFailureType tells failures apart by severity, as JUnit has no notion of it
+func junitFailureType(severity string) string {
+	switch severity {
+	case models.SeverityWarning:
+		return "ValidationWarning"
+	case models.SeverityInfo:
+		return "ValidationInfo"
+	}
+	return "ValidationFailed"
+}
+
+// failureBody describes a failed validation as plain text, with its evaluated objects rendered in the target format
+func failureBody(result models.EvaluationResult, format string, supressObjects bool) string {
+	var b strings.Builder
+	for _, detail := range ruleDetails(result) {
+		fmt.Fprintf(&b, "%s: %s\n", detail[0], detail[1])
+	}
+	b.WriteString(result.ValidationError.Error())
+	b.WriteString("\n")
+	if result.Explanation != nil {
+		b.WriteString("\nexplain:\n")
+		b.WriteString(FormatExplanation(result.Explanation))
+	}
+	if supressObjects {
+		return b.String()
+	}
+	for _, obj := range result.EvaluatedObjects {
+		fmt.Fprintf(&b, "\nobject: %s", obj.Expression)
+		if obj.Name != "" {
+			fmt.Fprintf(&b, " (%s)", obj.Name)
+		}
+		if obj.Position != nil {
+			fmt.Fprintf(&b, " %s", FormatPosition(*obj.Position))
+		}
+		b.WriteString("\n")
+		obj.Object = helpers.Normalize(obj.Object)
+		byteObj, err := marshalObject(obj, format)
+		if err != nil {
+			fmt.Fprintf(&b, "Error marshalling object: %v\n", err)
+			continue
+		}
+		b.Write(byteObj)
+	}
+	return b.String()
+}
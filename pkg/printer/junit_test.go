@@ -0,0 +1,98 @@
+package printer
+
+import (
+	"bytes"
+	"celify/pkg/models"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrintJUnit(t *testing.T) {
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{Format: "yaml", Source: "a.yaml", Kind: "Deployment", Name: "my-app"},
+			Results: []models.EvaluationResult{
+				{Expression: "object.spec.replicas > 1"},
+				{
+					Expression: "object.metadata.name == 'other'",
+					EvaluatedObjects: []models.EvaluatedObject{
+						{Expression: "object.metadata.name", Object: "my-app"},
+					},
+					Message:         "name must be other",
+					ValidationError: errors.New("message: name must be other"),
+				},
+			},
+		},
+		{
+			Target:  &models.TargetData{Format: "json", Document: 0},
+			Results: []models.EvaluationResult{{Expression: "object.spec.replicas > 1"}},
+		},
+	}
+
+	var b bytes.Buffer
+	p, err := NewPrinter(OutputJUnit, &b)
+	if err != nil {
+		t.Fatalf("Error creating printer: %v", err)
+	}
+	if err := p.PrintResults(targetResults, false); err != nil {
+		t.Fatalf("Error printing results: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshalling output: %v\n%s", err, b.String())
+	}
+	if report.Tests != 3 || report.Failures != 1 || len(report.Suites) != 2 {
+		t.Fatalf("Unexpected report totals: %+v", report)
+	}
+	suite := report.Suites[0]
+	if suite.Name != "a.yaml, document 0 (Deployment/my-app)" || suite.Tests != 2 || suite.Failures != 1 {
+		t.Errorf("Unexpected suite: %+v", suite)
+	}
+	failure := suite.Cases[1].Failure
+	if failure == nil {
+		t.Fatalf("Expected a failure on the second test case")
+	}
+	if failure.Message != "name must be other" {
+		t.Errorf("Expected failure message 'name must be other', got '%s'", failure.Message)
+	}
+	if !strings.Contains(failure.Body, "object: object.metadata.name\nmy-app\n") {
+		t.Errorf("Expected failure body to contain the evaluated object, got '%s'", failure.Body)
+	}
+	if report.Suites[1].Cases[0].ClassName != "inline" {
+		t.Errorf("Expected inline class name, got '%s'", report.Suites[1].Cases[0].ClassName)
+	}
+}
+
+func TestPrintJUnitSkipped(t *testing.T) {
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{Format: "yaml", Source: "a.yaml", Kind: "ConfigMap"},
+			Results: []models.EvaluationResult{
+				{Expression: "object.spec.replicas > 1", Skipped: true, Message: "match condition 'is-deployment' not met"},
+				{Expression: "has(object.data)"},
+			},
+		},
+	}
+	var b bytes.Buffer
+	p, err := NewPrinter(OutputJUnit, &b)
+	if err != nil {
+		t.Fatalf("Error creating printer: %v", err)
+	}
+	if err := p.PrintResults(targetResults, false); err != nil {
+		t.Fatalf("Error printing results: %v", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshalling output: %v\n%s", err, b.String())
+	}
+	if report.Tests != 2 || report.Failures != 0 || report.Skipped != 1 {
+		t.Fatalf("Unexpected report totals: %+v", report)
+	}
+	skipped := report.Suites[0].Cases[0].Skipped
+	if skipped == nil || skipped.Message != "match condition 'is-deployment' not met" {
+		t.Errorf("Expected the first test case to be skipped, got %+v", skipped)
+	}
+}
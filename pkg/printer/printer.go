@@ -2,76 +2,291 @@ package printer
 
 import (
 	"bytes"
-	"celify/pkg/evaluator"
 	"celify/pkg/helpers"
 	"celify/pkg/models"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 
 	"github.com/alecthomas/chroma/formatters"
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/styles"
 	"github.com/fatih/color"
+	"github.com/pkg/errors"
 )
 
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputJUnit = "junit"
+	OutputSARIF = "sarif"
+)
+
+// Outputs lists the supported output formats
+var Outputs = []string{OutputText, OutputJSON, OutputJUnit, OutputSARIF}
+
+// ansiRegex matches the escape sequences colouring text
+var ansiRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// elementNameRegex matches the last field selected by an expression, along with the index of a list item,
+// e.g. dependency in object.project.dependencies[0].dependency[1]
+var elementNameRegex = regexp.MustCompile(`(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[['"]([^'"]+)['"]\])(?:\[\d+\])?$`)
+
 type Printer struct {
-	Evaluator *evaluator.Evaluator
+	Output string
+	Writer io.Writer
 }
 
-func NewPrinter(evaluator *evaluator.Evaluator) *Printer {
-	return &Printer{
-		Evaluator: evaluator,
+func NewPrinter(output string, writer io.Writer) (*Printer, error) {
+	if output == "" {
+		output = OutputText
 	}
+	for _, supported := range Outputs {
+		if output == supported {
+			return &Printer{
+				Output: output,
+				Writer: writer,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("Invalid output '%s' provided, expected one of: %s", output, strings.Join(Outputs, ", "))
 }
 
-func (p *Printer) PrintResults(results []models.EvaluationResult, supressObjects bool) {
-	fmt.Println()
-	for _, result := range results {
-		color.New(color.Bold).Add(color.Underline).Printf("validation \"%s\":\n", result.Expression)
-		if result.ValidationError != nil {
-			fmt.Printf("%s %s\n", getErrorStr(), color.YellowString(result.ValidationError.Error()))
-			fmt.Printf("%s\n", getErrorStr())
-			if !supressObjects {
-				printEvaluatedObjects(result.EvaluatedObjects, p.Evaluator.TargetData.Format)
+// noColorWriter strips colours from everything written to it
+type noColorWriter struct {
+	writer io.Writer
+}
+
+// NoColor wraps a writer, like a file, to write results to it without colours
+func NoColor(writer io.Writer) io.Writer {
+	return noColorWriter{writer: writer}
+}
+
+func (w noColorWriter) Write(p []byte) (int, error) {
+	if _, err := w.writer.Write(ansiRegex.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// PrintResults writes the results of every target document in the printer's output format
+func (p *Printer) PrintResults(targetResults []models.TargetResult, supressObjects bool) error {
+	switch p.Output {
+	case OutputJSON:
+		return p.printJSON(targetResults, supressObjects)
+	case OutputJUnit:
+		return p.printJUnit(targetResults, supressObjects)
+	case OutputSARIF:
+		return p.printSARIF(targetResults)
+	default:
+		p.printText(targetResults, supressObjects)
+		return nil
+	}
+}
+
+// printText prints human readable results, labelling each group when more than one document was evaluated
+func (p *Printer) printText(targetResults []models.TargetResult, supressObjects bool) {
+	fmt.Fprintln(p.Writer)
+	for _, targetResult := range targetResults {
+		if len(targetResults) > 1 {
+			color.New(color.Bold).Add(color.FgCyan).Fprintf(p.Writer, "%s\n", TargetLabel(targetResult.Target))
+		}
+		for _, result := range targetResult.Results {
+			color.New(color.Bold).Add(color.Underline).Fprintf(p.Writer, "validation \"%s\":\n", RuleLabel(result))
+			if result.ValidationError != nil {
+				printRuleDetails(p.Writer, result)
+				severity := result.Rule.EffectiveSeverity()
+				fmt.Fprintf(p.Writer, "%s %s %s\n", getErrorStr(), severityColor(severity).Sprintf("[%s]", severity), color.YellowString(result.ValidationError.Error()))
+				fmt.Fprintf(p.Writer, "%s\n", getErrorStr())
+				if result.Explanation != nil {
+					printExplanation(p.Writer, result.Explanation)
+				}
+				if !supressObjects {
+					printEvaluatedObjects(p.Writer, result.EvaluatedObjects, targetResult.Target.Format)
+				}
+				continue
+			}
+			if result.Skipped {
+				color.New(color.Faint).Fprintf(p.Writer, "Skipped: %s\n", result.Message)
+				fmt.Fprintln(p.Writer)
+				continue
+			}
+			color.New(color.FgGreen).Fprintln(p.Writer, "Success: true")
+			fmt.Fprintln(p.Writer)
+		}
+	}
+	if len(targetResults) > 1 {
+		p.printSummary(targetResults)
+	}
+}
+
+func (p *Printer) printSummary(targetResults []models.TargetResult) {
+	summary := Summarize(targetResults)
+	line := fmt.Sprintf("Validated %d documents from %d targets: %d passed, %d failed", summary.Documents, summary.Targets, summary.Passed, summary.Failed)
+	if summary.Failed > 0 {
+		color.New(color.Bold).Add(color.FgRed).Fprintln(p.Writer, line)
+	} else {
+		color.New(color.Bold).Add(color.FgGreen).Fprintln(p.Writer, line)
+	}
+}
+
+type Summary struct {
+	Targets   int `json:"targets"`
+	Documents int `json:"documents"`
+	Passed    int `json:"passed"`
+	Failed    int `json:"failed"`
+}
+
+// Summarize counts the targets and documents evaluated, a document failing when any of its validations failed
+func Summarize(targetResults []models.TargetResult) Summary {
+	sources := map[string]bool{}
+	summary := Summary{Documents: len(targetResults)}
+	for _, targetResult := range targetResults {
+		sources[targetResult.Target.Source] = true
+		for _, result := range targetResult.Results {
+			if result.ValidationError != nil {
+				summary.Failed++
+				break
 			}
-			continue
 		}
-		color.New(color.FgGreen).Println("Success: true")
-		fmt.Println()
 	}
+	summary.Targets = len(sources)
+	summary.Passed = summary.Documents - summary.Failed
+	return summary
+}
+
+// TargetLabel describes a target document by its source file, its index and, when present, its kind and name
+func TargetLabel(target *models.TargetData) string {
+	label := fmt.Sprintf("document %d", target.Document)
+	if target.Source != "" {
+		label = fmt.Sprintf("%s, %s", target.Source, label)
+	}
+	if target.Kind != "" && target.Name != "" {
+		label = fmt.Sprintf("%s (%s/%s)", label, target.Kind, target.Name)
+	} else if target.Kind != "" || target.Name != "" {
+		label = fmt.Sprintf("%s (%s%s)", label, target.Kind, target.Name)
+	}
+	return label
+}
+
+// RuleLabel names the validation of a result by its name and id, falling back to its expression when it has neither
+func RuleLabel(result models.EvaluationResult) string {
+	rule := result.Rule
+	switch {
+	case rule.Name != "" && rule.ID != "":
+		return fmt.Sprintf("%s (%s)", rule.Name, rule.ID)
+	case rule.Name != "":
+		return rule.Name
+	case rule.ID != "":
+		return rule.ID
+	}
+	return result.Expression
+}
+
+// isLabelled tells whether a result is labelled by something other than its expression
+func isLabelled(result models.EvaluationResult) bool {
+	return result.Rule.ID != "" || result.Rule.Name != ""
+}
+
+// printRuleDetails prints the expression of a labelled validation along with its description, documentation and tags
+func printRuleDetails(w io.Writer, result models.EvaluationResult) {
+	details := ruleDetails(result)
+	if len(details) == 0 {
+		return
+	}
+	for _, detail := range details {
+		fmt.Fprintf(w, "%s %s %s\n", getErrorStr(), color.New(color.Underline).Sprintf("%s:", detail[0]), detail[1])
+	}
+	fmt.Fprintf(w, "%s\n", getErrorStr())
+}
+
+// ruleDetails lists the rule fields worth showing along with a failure, as label and value pairs
+func ruleDetails(result models.EvaluationResult) [][2]string {
+	rule := result.Rule
+	details := [][2]string{}
+	if isLabelled(result) {
+		details = append(details, [2]string{"expression", result.Expression})
+	}
+	if rule.Description != "" {
+		details = append(details, [2]string{"description", rule.Description})
+	}
+	if rule.DocumentationURL != "" {
+		details = append(details, [2]string{"documentation", rule.DocumentationURL})
+	}
+	if len(rule.Tags) > 0 {
+		details = append(details, [2]string{"tags", strings.Join(rule.Tags, ", ")})
+	}
+	return details
+}
+
+// severityColor tells failed validations apart by severity: errors in red, warnings in yellow and infos in cyan
+func severityColor(severity string) *color.Color {
+	switch severity {
+	case models.SeverityWarning:
+		return color.New(color.Bold).Add(color.FgYellow)
+	case models.SeverityInfo:
+		return color.New(color.Bold).Add(color.FgCyan)
+	}
+	return color.New(color.Bold).Add(color.FgRed)
 }
 
 func getErrorStr() string {
 	return color.New(color.FgRed).Sprint("|")
 }
 
-func PrintMultilineError(input string, color *color.Color) {
+func PrintMultilineError(w io.Writer, input string, color *color.Color) {
 	errLines := strings.Split(input, "\n")
 	for _, line := range errLines {
-		fmt.Printf("%s %s\n", getErrorStr(), color.Sprint(line))
+		fmt.Fprintf(w, "%s %s\n", getErrorStr(), color.Sprint(line))
 	}
 }
 
-func printEvaluatedObjects(objects []models.EvaluatedObject, format string) {
+func printEvaluatedObjects(w io.Writer, objects []models.EvaluatedObject, format string) {
 	for _, obj := range objects {
-		byteObj, err := helpers.MarshalData(obj.Object, format)
+		byteObj, err := marshalObject(obj, format)
 		if err != nil {
-			fmt.Printf("%s %s\n", getErrorStr(), color.New(color.FgRed).Sprint("Error marshalling object"))
+			fmt.Fprintf(w, "%s %s\n", getErrorStr(), color.New(color.FgRed).Sprint("Error marshalling object"))
 			return
 		}
 		strObj := string(byteObj)
-		fmt.Printf("%s %s %s\n", getErrorStr(), color.New(color.Underline).Sprint("object:"), obj.Expression)
-		if format == "yaml" {
-			colorizedYaml := colorizeYaml(strObj)
-			PrintMultilineError(colorizedYaml, color.New(color.Reset))
-		} else if format == "json" {
-			colorizedJson := colorizeJson(strObj)
-			PrintMultilineError(colorizedJson, color.New(color.Reset))
+		header := fmt.Sprintf("%s %s %s", getErrorStr(), color.New(color.Underline).Sprint("object:"), obj.Expression)
+		if obj.Name != "" {
+			header = fmt.Sprintf("%s (%s)", header, obj.Name)
+		}
+		if obj.Position != nil {
+			header = fmt.Sprintf("%s %s", header, color.New(color.FgCyan).Sprint(FormatPosition(*obj.Position)))
 		}
+		fmt.Fprintln(w, header)
+		PrintMultilineError(w, colorize(strObj, format), color.New(color.Reset))
 	}
 }
 
+// marshalObject marshals an evaluated object in the format of its target, XML elements being named after the field
+// the expression selects them with, as the object itself doesn't hold its name
+func marshalObject(obj models.EvaluatedObject, format string) ([]byte, error) {
+	if format != helpers.FormatXML {
+		return helpers.MarshalData(obj.Object, format)
+	}
+	match := elementNameRegex.FindStringSubmatch(obj.Expression)
+	if match == nil {
+		return helpers.MarshalData(obj.Object, format)
+	}
+	name := match[1] + match[2]
+	if strings.HasPrefix(name, helpers.XMLAttributePrefix) || name == helpers.XMLTextKey {
+		return helpers.MarshalData(obj.Object, format)
+	}
+	return helpers.MarshalXMLElement(name, obj.Object)
+}
+
+// FormatPosition formats a position as file:line:column, leaving the file out for inline targets
+func FormatPosition(position models.Position) string {
+	if position.File == "" {
+		return fmt.Sprintf("%d:%d", position.Line, position.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", position.File, position.Line, position.Column)
+}
+
 func FmtError(err error) error {
 	fmt.Println()
 	summaryStr := color.New(color.FgRed).Add(color.Underline).Add(color.Bold).Sprint("Error Summary:")
@@ -79,29 +294,16 @@ func FmtError(err error) error {
 	return fmt.Errorf("%s\n%s", summaryStr, errStr)
 }
 
-func colorizeJson(jsonStr string) string {
-	// return string(pretty.Color(pretty.Pretty([]byte(jsonStr)), nil))
-	lexer := lexers.Get("json")
-	style := styles.Get("solarized-light")
-	formatter := formatters.Get("terminal")
-
-	iterator, err := lexer.Tokenise(nil, jsonStr)
-	if err != nil {
-		panic(err)
+// colorize highlights data with the chroma lexer named after its format
+func colorize(data, format string) string {
+	lexer := lexers.Get(format)
+	if lexer == nil {
+		lexer = lexers.Fallback
 	}
-
-	var b bytes.Buffer
-	writer := io.Writer(&b)
-	formatter.Format(writer, style, iterator)
-	return b.String()
-}
-
-func colorizeYaml(yamlStr string) string {
-	lexer := lexers.Get("yaml")
 	style := styles.Get("solarized-light")
 	formatter := formatters.Get("terminal")
 
-	iterator, err := lexer.Tokenise(nil, yamlStr)
+	iterator, err := lexer.Tokenise(nil, data)
 	if err != nil {
 		panic(err)
 	}
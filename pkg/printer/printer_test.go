@@ -0,0 +1,98 @@
+package printer
+
+import (
+	"bytes"
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestMarshalObject(t *testing.T) {
+	dependency := map[string]interface{}{"@scope": "test", "artifactId": []interface{}{"junit"}}
+	testCases := []struct {
+		expression string
+		object     interface{}
+		format     string
+		expected   string
+	}{
+		{
+			expression: "object.project.dependencies[0].dependency[1]",
+			object:     dependency,
+			format:     helpers.FormatXML,
+			expected:   "<dependency scope=\"test\">\n  <artifactId>junit</artifactId>\n</dependency>\n",
+		},
+		{
+			expression: "object.project.properties[0][\"spring-boot.version\"]",
+			object:     []interface{}{"3.2.0"},
+			format:     helpers.FormatXML,
+			expected:   "<spring-boot.version>3.2.0</spring-boot.version>\n",
+		},
+		{
+			expression: "object.project[\"@xmlns\"]",
+			object:     "http://maven.apache.org/POM/4.0.0",
+			format:     helpers.FormatXML,
+			expected:   "http://maven.apache.org/POM/4.0.0\n",
+		},
+		{
+			expression: "object",
+			object:     map[string]interface{}{"project": map[string]interface{}{"modelVersion": []interface{}{"4.0.0"}}},
+			format:     helpers.FormatXML,
+			expected:   "<project>\n  <modelVersion>4.0.0</modelVersion>\n</project>\n",
+		},
+		{
+			expression: "object.project.dependencies[0].dependency[1]",
+			object:     dependency,
+			format:     helpers.FormatJSON,
+			expected:   "{\n  \"@scope\": \"test\",\n  \"artifactId\": [\n    \"junit\"\n  ]\n}\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			actual, err := marshalObject(models.EvaluatedObject{Expression: tc.expression, Object: tc.object}, tc.format)
+			if err != nil {
+				t.Fatalf("Error marshalling object: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	defer func(original bool) { color.NoColor = original }(color.NoColor)
+	color.NoColor = false
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{Format: helpers.FormatYAML},
+			Results: []models.EvaluationResult{
+				{
+					Expression:       "object.replicas > 1",
+					EvaluatedObjects: []models.EvaluatedObject{{Expression: "object.replicas", Object: 1}},
+					ValidationError:  errors.New("message: validation failed"),
+				},
+			},
+		},
+	}
+	var b bytes.Buffer
+	p, err := NewPrinter(OutputText, NoColor(&b))
+	if err != nil {
+		t.Fatalf("Error creating printer: %v", err)
+	}
+	if err := p.PrintResults(targetResults, false); err != nil {
+		t.Fatalf("Error printing results: %v", err)
+	}
+	if strings.Contains(b.String(), "\x1b[") {
+		t.Errorf("Expected no colours, got %q", b.String())
+	}
+	if !strings.Contains(b.String(), "validation \"object.replicas > 1\":") {
+		t.Errorf("Expected the results to be printed, got %q", b.String())
+	}
+	if color.NoColor {
+		t.Errorf("Expected colours to stay enabled for other writers")
+	}
+}
@@ -0,0 +1,181 @@
+package printer
+
+import (
+	"celify/pkg/models"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []sarifRuleDescriptor `json:"rules"`
+}
+
+type sarifRuleDescriptor struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      *sarifMessage      `json:"fullDescription,omitempty"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           *sarifProperties   `json:"properties,omitempty"`
+}
+
+type sarifProperties struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// printSARIF prints a SARIF 2.1.0 log with a rule descriptor per validation and a result per failed validation.
+// Every target is evaluated against the same rules, so the descriptors are taken from the first target's results.
+func (p *Printer) printSARIF(targetResults []models.TargetResult) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "celify",
+				InformationURI: "https://github.com/rdalbuquerque/celify",
+				Rules:          []sarifRuleDescriptor{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+	if len(targetResults) > 0 {
+		for i, result := range targetResults[0].Results {
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifDescriptor(i, result))
+		}
+	}
+	for _, targetResult := range targetResults {
+		for i, result := range targetResult.Results {
+			if result.ValidationError == nil {
+				continue
+			}
+			sResult := sarifResult{
+				RuleID:    sarifRuleID(i, result.Rule),
+				RuleIndex: i,
+				Level:     sarifLevel(result.Rule.EffectiveSeverity()),
+				Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", TargetLabel(targetResult.Target), result.Message)},
+			}
+			if targetResult.Target.Source != "" {
+				sResult.Locations = []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(targetResult.Target.Source)},
+						Region:           sarifResultRegion(result),
+					},
+				}}
+			}
+			run.Results = append(run.Results, sResult)
+		}
+	}
+	report := sarifReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(p.Writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(report)
+}
+
+// sarifResultRegion points at the first evaluated object with a known position, leaving the region out when none has one
+func sarifResultRegion(result models.EvaluationResult) *sarifRegion {
+	for _, obj := range result.EvaluatedObjects {
+		if obj.Position != nil {
+			return &sarifRegion{StartLine: obj.Position.Line, StartColumn: obj.Position.Column}
+		}
+	}
+	return nil
+}
+
+// sarifDescriptor describes a validation, using its name as short description and its expression when it has none
+func sarifDescriptor(index int, result models.EvaluationResult) sarifRuleDescriptor {
+	rule := result.Rule
+	descriptor := sarifRuleDescriptor{
+		ID:                   sarifRuleID(index, rule),
+		Name:                 rule.Name,
+		ShortDescription:     sarifMessage{Text: result.Expression},
+		HelpURI:              rule.DocumentationURL,
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.EffectiveSeverity())},
+	}
+	if rule.Name != "" {
+		descriptor.ShortDescription = sarifMessage{Text: rule.Name}
+	}
+	if rule.Description != "" {
+		descriptor.FullDescription = &sarifMessage{Text: rule.Description}
+	}
+	if len(rule.Tags) > 0 {
+		descriptor.Properties = &sarifProperties{Tags: rule.Tags}
+	}
+	return descriptor
+}
+
+// sarifRuleID is the id of the rule when it has one, or one derived from its position in the validations
+func sarifRuleID(index int, rule models.ValidationRule) string {
+	if rule.ID != "" {
+		return rule.ID
+	}
+	return fmt.Sprintf("celify/rule-%d", index+1)
+}
+
+// sarifLevel maps a rule severity to a SARIF level, infos being notes
+func sarifLevel(severity string) string {
+	if severity == models.SeverityInfo {
+		return "note"
+	}
+	return severity
+}
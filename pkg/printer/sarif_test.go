@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"bytes"
+	"celify/pkg/models"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPrintSARIF(t *testing.T) {
+	targetResults := []models.TargetResult{
+		{
+			Target: &models.TargetData{
+				Format: "yaml", Source: "manifests/app.yaml", Document: 1, Kind: "Deployment", Name: "my-app",
+				Positions: map[string]models.Position{"": {File: "manifests/app.yaml", Line: 1, Column: 1}},
+			},
+			Results: []models.EvaluationResult{
+				{Expression: "object.spec.replicas > 1"},
+				{
+					Expression:      "object.metadata.name == 'other'",
+					Message:         "name must be other",
+					ValidationError: errors.New("message: name must be other"),
+				},
+			},
+		},
+		{
+			Target: &models.TargetData{Format: "json"},
+			Results: []models.EvaluationResult{
+				{
+					Expression:      "object.spec.replicas > 1",
+					Message:         "validation failed",
+					ValidationError: errors.New("message: validation failed"),
+				},
+				{Expression: "object.metadata.name == 'other'"},
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	p, err := NewPrinter(OutputSARIF, &b)
+	if err != nil {
+		t.Fatalf("Error creating printer: %v", err)
+	}
+	if err := p.PrintResults(targetResults, false); err != nil {
+		t.Fatalf("Error printing results: %v", err)
+	}
+
+	var report sarifReport
+	if err := json.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshalling output: %v\n%s", err, b.String())
+	}
+	if report.Version != "2.1.0" || len(report.Runs) != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	run := report.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("Expected 2 rule descriptors, got %d", len(run.Tool.Driver.Rules))
+	}
+	if run.Tool.Driver.Rules[1].ID != "celify/rule-2" || run.Tool.Driver.Rules[1].ShortDescription.Text != "object.metadata.name == 'other'" {
+		t.Errorf("Unexpected rule descriptor: %+v", run.Tool.Driver.Rules[1])
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(run.Results))
+	}
+	first := run.Results[0]
+	if first.RuleID != "celify/rule-2" || first.RuleIndex != 1 || first.Message.Text != "manifests/app.yaml, document 1 (Deployment/my-app): name must be other" {
+		t.Errorf("Unexpected result: %+v", first)
+	}
+	if len(first.Locations) != 1 || first.Locations[0].PhysicalLocation.ArtifactLocation.URI != "manifests/app.yaml" {
+		t.Fatalf("Unexpected locations: %+v", first.Locations)
+	}
+	if region := first.Locations[0].PhysicalLocation.Region; region != nil {
+		t.Errorf("Expected no region without positioned objects, got %+v", region)
+	}
+	if second := run.Results[1]; second.RuleID != "celify/rule-1" || len(second.Locations) != 0 {
+		t.Errorf("Unexpected result for inline target: %+v", second)
+	}
+}
+
+func TestSARIFDescriptorUsesRuleMetadata(t *testing.T) {
+	result := models.EvaluationResult{
+		Rule: models.ValidationRule{
+			ID:               "replicas",
+			Name:             "Minimum replicas",
+			Description:      "Deployments must run more than one replica",
+			DocumentationURL: "https://example.com/replicas",
+			Tags:             []string{"availability"},
+		},
+		Expression: "object.spec.replicas > 1",
+	}
+	descriptor := sarifDescriptor(0, result)
+	if descriptor.ID != "replicas" || descriptor.Name != "Minimum replicas" || descriptor.ShortDescription.Text != "Minimum replicas" {
+		t.Errorf("Unexpected descriptor: %+v", descriptor)
+	}
+	if descriptor.FullDescription == nil || descriptor.FullDescription.Text != "Deployments must run more than one replica" {
+		t.Errorf("Unexpected full description: %+v", descriptor.FullDescription)
+	}
+	if descriptor.HelpURI != "https://example.com/replicas" {
+		t.Errorf("Unexpected help uri: %s", descriptor.HelpURI)
+	}
+	if descriptor.Properties == nil || len(descriptor.Properties.Tags) != 1 || descriptor.Properties.Tags[0] != "availability" {
+		t.Errorf("Unexpected properties: %+v", descriptor.Properties)
+	}
+}
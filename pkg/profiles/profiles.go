@@ -0,0 +1,33 @@
+// Package profiles holds the profiles tailoring expressions to a kind of target, with variables indexing the target
+// and helpers built on them, so rules don't have to walk its raw structure
+package profiles
+
+import (
+	"celify/pkg/evaluator"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var profiles = map[string]*evaluator.Profile{
+	TerraformPlan.Name: TerraformPlan,
+}
+
+// Names lists the available profiles in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the profile with the given name
+func Get(name string) (*evaluator.Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, errors.Errorf("Invalid profile '%s' provided, expected one of: %v", name, Names())
+	}
+	return profile, nil
+}
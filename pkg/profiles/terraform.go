@@ -0,0 +1,209 @@
+package profiles
+
+import (
+	"celify/pkg/evaluator"
+	"celify/pkg/helpers"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/parser"
+)
+
+// UnknownValue marks the attributes of a planned resource that are only known after apply, as in terraform plan
+const UnknownValue = "(known after apply)"
+
+// resourceVar is the iteration variable of the comprehension resourcesByType expands to
+const resourceVar = "__resource__"
+
+// TerraformPlan indexes the resource changes of a plan, as printed by terraform show -json, into:
+//   - resources, the list of resource changes, each one with its address, module, mode, type, name, index, provider,
+//     actions, action (one of create, read, update, delete, replace and no-op), before and after values, and the
+//     paths of the attributes unknown until apply, which are UnknownValue in after
+//   - changes, the resources to create, update, delete and replace, replaced resources being created and deleted too
+//   - resourcesByType(type), the resources of the given type
+var TerraformPlan = &evaluator.Profile{
+	Name: "terraform-plan",
+	Variables: map[string]*cel.Type{
+		"resources": cel.ListType(cel.DynType),
+		"changes":   cel.MapType(cel.StringType, cel.ListType(cel.DynType)),
+	},
+	Macros: []cel.Macro{
+		parser.NewGlobalMacro("resourcesByType", 1, expandResourcesByType),
+	},
+	Compute: indexPlan,
+}
+
+// expandResourcesByType expands resourcesByType(type) into resources.filter(r, r.type == type)
+func expandResourcesByType(eh parser.ExprHelper, _ ast.Expr, args []ast.Expr) (ast.Expr, *common.Error) {
+	predicate := eh.NewCall(operators.Equals, eh.NewSelect(eh.NewIdent(resourceVar), "type"), args[0])
+	return parser.MakeFilter(eh, eh.NewIdent("resources"), []ast.Expr{eh.NewIdent(resourceVar), predicate})
+}
+
+func indexPlan(object interface{}) map[string]interface{} {
+	object = helpers.Normalize(object)
+	resources := []interface{}{}
+	changes := map[string][]interface{}{
+		"creates":  {},
+		"updates":  {},
+		"deletes":  {},
+		"replaces": {},
+	}
+	resourceChanges, _ := lookup(object, "resource_changes").([]interface{})
+	for _, rc := range resourceChanges {
+		resource := planResource(rc)
+		resources = append(resources, resource)
+		switch resource["action"] {
+		case "create":
+			changes["creates"] = append(changes["creates"], resource)
+		case "update":
+			changes["updates"] = append(changes["updates"], resource)
+		case "delete":
+			changes["deletes"] = append(changes["deletes"], resource)
+		case "replace":
+			changes["replaces"] = append(changes["replaces"], resource)
+			changes["creates"] = append(changes["creates"], resource)
+			changes["deletes"] = append(changes["deletes"], resource)
+		}
+	}
+	changesValue := map[string]interface{}{}
+	for kind, list := range changes {
+		changesValue[kind] = list
+	}
+	return map[string]interface{}{
+		"resources": resources,
+		"changes":   changesValue,
+	}
+}
+
+func planResource(rc interface{}) map[string]interface{} {
+	change := lookup(rc, "change")
+	actions, _ := lookup(change, "actions").([]interface{})
+	if actions == nil {
+		actions = []interface{}{}
+	}
+	afterUnknown := lookup(change, "after_unknown")
+	unknown := []interface{}{}
+	unknownPaths(afterUnknown, []interface{}{}, &unknown)
+	return map[string]interface{}{
+		"address":  lookup(rc, "address"),
+		"module":   stringOr(lookup(rc, "module_address")),
+		"mode":     lookup(rc, "mode"),
+		"type":     lookup(rc, "type"),
+		"name":     lookup(rc, "name"),
+		"index":    lookup(rc, "index"),
+		"provider": lookup(rc, "provider_name"),
+		"actions":  actions,
+		"action":   action(actions),
+		"before":   lookup(change, "before"),
+		"after":    markUnknown(lookup(change, "after"), afterUnknown),
+		"unknown":  unknown,
+	}
+}
+
+// action summarizes the actions of a resource change, a delete and a create in any order being a replace
+func action(actions []interface{}) string {
+	if len(actions) == 2 {
+		return "replace"
+	}
+	if len(actions) == 1 {
+		if a, ok := actions[0].(string); ok {
+			return a
+		}
+	}
+	return "no-op"
+}
+
+// markUnknown sets the attributes flagged by after_unknown, which mirrors the structure of after, to UnknownValue
+func markUnknown(after, unknown interface{}) interface{} {
+	if after == nil && !hasUnknown(unknown) {
+		return nil
+	}
+	switch u := unknown.(type) {
+	case bool:
+		if u {
+			return UnknownValue
+		}
+	case map[string]interface{}:
+		afterMap, ok := after.(map[string]interface{})
+		if !ok && after != nil {
+			return after
+		}
+		marked := make(map[string]interface{}, len(afterMap))
+		for key, value := range afterMap {
+			marked[key] = value
+		}
+		for key, unknownValue := range u {
+			if value := markUnknown(afterMap[key], unknownValue); value != nil {
+				marked[key] = value
+			}
+		}
+		return marked
+	case []interface{}:
+		afterList, ok := after.([]interface{})
+		if !ok && after != nil {
+			return after
+		}
+		marked := make([]interface{}, 0, len(afterList))
+		for i := 0; i < len(afterList) || i < len(u); i++ {
+			var value, unknownValue interface{}
+			if i < len(afterList) {
+				value = afterList[i]
+			}
+			if i < len(u) {
+				unknownValue = u[i]
+			}
+			marked = append(marked, markUnknown(value, unknownValue))
+		}
+		return marked
+	}
+	return after
+}
+
+func hasUnknown(unknown interface{}) bool {
+	paths := []interface{}{}
+	unknownPaths(unknown, []interface{}{"unknown"}, &paths)
+	return len(paths) > 0
+}
+
+// unknownPaths collects the path of every attribute flagged by after_unknown, e.g. arn or ingress[0].id
+func unknownPaths(unknown interface{}, path []interface{}, paths *[]interface{}) {
+	switch u := unknown.(type) {
+	case bool:
+		if u && len(path) > 0 {
+			*paths = append(*paths, helpers.PathKey(path))
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(u) {
+			unknownPaths(u[key], append(append([]interface{}{}, path...), key), paths)
+		}
+	case []interface{}:
+		for i, value := range u {
+			unknownPaths(value, append(append([]interface{}{}, path...), i), paths)
+		}
+	}
+}
+
+func lookup(obj interface{}, key string) interface{} {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
+
+func stringOr(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
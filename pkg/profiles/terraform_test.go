@@ -0,0 +1,133 @@
+package profiles
+
+import (
+	"celify/pkg/evaluator"
+	"celify/pkg/models"
+	"reflect"
+	"testing"
+)
+
+var plan = map[string]interface{}{
+	"resource_changes": []interface{}{
+		map[string]interface{}{
+			"address": "aws_s3_bucket.logs",
+			"mode":    "managed",
+			"type":    "aws_s3_bucket",
+			"name":    "logs",
+			"change": map[string]interface{}{
+				"actions":       []interface{}{"create"},
+				"before":        nil,
+				"after":         map[string]interface{}{"bucket": "logs", "versioning": []interface{}{map[string]interface{}{"enabled": false}}},
+				"after_unknown": map[string]interface{}{"arn": true, "versioning": []interface{}{map[string]interface{}{"mfa_delete": true}}},
+			},
+		},
+		map[string]interface{}{
+			"address":        "module.db.aws_db_instance.main",
+			"module_address": "module.db",
+			"mode":           "managed",
+			"type":           "aws_db_instance",
+			"name":           "main",
+			"change": map[string]interface{}{
+				"actions":       []interface{}{"delete", "create"},
+				"before":        map[string]interface{}{"instance_class": "db.t3.micro"},
+				"after":         map[string]interface{}{"instance_class": "db.t3.large"},
+				"after_unknown": map[string]interface{}{},
+			},
+		},
+		map[string]interface{}{
+			"address": "aws_iam_role.old",
+			"mode":    "managed",
+			"type":    "aws_iam_role",
+			"name":    "old",
+			"change": map[string]interface{}{
+				"actions":       []interface{}{"delete"},
+				"before":        map[string]interface{}{"name": "old"},
+				"after":         nil,
+				"after_unknown": map[string]interface{}{},
+			},
+		},
+	},
+}
+
+func TestIndexPlan(t *testing.T) {
+	index := indexPlan(plan)
+	resources := index["resources"].([]interface{})
+	if len(resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %d", len(resources))
+	}
+	bucket := resources[0].(map[string]interface{})
+	expectedAfter := map[string]interface{}{
+		"bucket":     "logs",
+		"arn":        UnknownValue,
+		"versioning": []interface{}{map[string]interface{}{"enabled": false, "mfa_delete": UnknownValue}},
+	}
+	if !reflect.DeepEqual(bucket["after"], expectedAfter) {
+		t.Errorf("Expected after %v, got %v", expectedAfter, bucket["after"])
+	}
+	if expected := []interface{}{"arn", "versioning[0].mfa_delete"}; !reflect.DeepEqual(bucket["unknown"], expected) {
+		t.Errorf("Expected unknown %v, got %v", expected, bucket["unknown"])
+	}
+	if deleted := resources[2].(map[string]interface{}); deleted["after"] != nil || deleted["module"] != "" {
+		t.Errorf("Expected a deleted root module resource, got %v", deleted)
+	}
+	changes := index["changes"].(map[string]interface{})
+	for kind, expected := range map[string][]string{
+		"creates":  {"aws_s3_bucket.logs", "module.db.aws_db_instance.main"},
+		"updates":  {},
+		"deletes":  {"module.db.aws_db_instance.main", "aws_iam_role.old"},
+		"replaces": {"module.db.aws_db_instance.main"},
+	} {
+		actual := []string{}
+		for _, resource := range changes[kind].([]interface{}) {
+			actual = append(actual, resource.(map[string]interface{})["address"].(string))
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected %s %v, got %v", kind, expected, actual)
+		}
+	}
+}
+
+func TestTerraformPlanExpressions(t *testing.T) {
+	testCases := []struct {
+		expression string
+		passes     bool
+	}{
+		{expression: "size(resources) == 3", passes: true},
+		{expression: "resourcesByType('aws_s3_bucket').all(b, b.after.versioning[0].enabled)", passes: false},
+		{expression: "resourcesByType('aws_s3_bucket').all(b, b.after.arn == '(known after apply)')", passes: true},
+		{expression: "changes.deletes.size() == 0", passes: false},
+		{expression: "changes.replaces.all(r, r.before.instance_class != r.after.instance_class)", passes: true},
+		{expression: "resources.exists(r, r.module == 'module.db' && r.action == 'replace')", passes: true},
+	}
+	runner, err := evaluator.NewRunner(1)
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	if err := runner.Evaluator.UseProfile(TerraformPlan); err != nil {
+		t.Fatalf("Error using profile: %v", err)
+	}
+	validations := models.ValidationConfig{}
+	for _, tc := range testCases {
+		validations.Validations = append(validations.Validations, models.ValidationRule{Expression: tc.expression})
+	}
+	ruleSet, err := runner.Compile(validations)
+	if err != nil {
+		t.Fatalf("Error compiling validations: %v", err)
+	}
+	target := &models.TargetData{Data: map[string]interface{}{"object": plan}, Format: "json"}
+	results := runner.Run(ruleSet, []*models.TargetData{target})[0].Results
+	for i, tc := range testCases {
+		if passed := results[i].ValidationError == nil; passed != tc.passes {
+			t.Errorf("Expected '%s' to pass: %v, got %v", tc.expression, tc.passes, results[i].ValidationError)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if _, err := Get("terraform-plan"); err != nil {
+		t.Errorf("Expected the terraform-plan profile, got %v", err)
+	}
+	if _, err := Get("helm"); err == nil {
+		t.Errorf("Expected an error for an unknown profile, got none")
+	}
+}
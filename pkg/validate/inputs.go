@@ -0,0 +1,75 @@
+package validate
+
+import (
+	"os"
+	"strings"
+
+	"celify/pkg/helpers"
+
+	"github.com/pkg/errors"
+)
+
+// FilePrefix marks an input as a path, so it's never taken for inline data
+const FilePrefix = "@"
+
+// input is the data of a target, validations or params input
+type input struct {
+	raw  string
+	data []byte
+	// source is the file the data was read from, empty for inline data
+	source string
+	// format is the format the data is in, empty when it has to be detected
+	format string
+	// stdin tells the data was read from stdin, so it's inline data whatever it looks like
+	stdin bool
+}
+
+// readInput reads an input from the file it names or, when no such file exists, takes it as inline data. Inputs
+// prefixed with FilePrefix are always files and StdinInput is the data read from stdin. The format is the given one,
+// else the one of the file extension.
+func readInput(raw, format string, stdinData []byte) (input, error) {
+	if err := helpers.ValidateFormat(format); err != nil {
+		return input{}, err
+	}
+	if raw == StdinInput && stdinData != nil {
+		return input{raw: string(stdinData), data: stdinData, format: format, stdin: true}, nil
+	}
+	path, isPath := strings.CutPrefix(raw, FilePrefix)
+	if !isPath && !isFile(raw) {
+		return input{raw: raw, data: []byte(raw), format: format}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return input{}, errors.Errorf("Error reading data: %v", err)
+	}
+	if format == "" {
+		format = helpers.FormatOf(path)
+	}
+	return input{raw: raw, data: data, source: path, format: format}, nil
+}
+
+// notFound reports inline data decoding to a single line YAML string as a missing file: any string is a valid YAML
+// document, so it most likely names a file that doesn't exist
+func (in input) notFound(docs []interface{}, format string) error {
+	if in.source != "" || in.stdin || format != helpers.FormatYAML || len(docs) != 1 || strings.Contains(in.raw, "\n") {
+		return nil
+	}
+	if _, isString := docs[0].(string); !isString {
+		return nil
+	}
+	_, err := os.ReadFile(in.raw)
+	return errors.Errorf("Error reading data: %v", err)
+}
+
+// parseError names the file that failed to parse, if any
+func (in input) parseError(what string, err error) error {
+	if in.source != "" {
+		return errors.Errorf("Error parsing %s '%s': %v", what, in.source, err)
+	}
+	return errors.Errorf("Error parsing %s: %v", what, err)
+}
+
+func isFile(input string) bool {
+	info, err := os.Stat(input)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,183 @@
+package validate
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+)
+
+// DefaultInclude lists the patterns used to pick files when walking a target directory without include patterns,
+// one for every extension of a known format
+var DefaultInclude = defaultInclude()
+
+func defaultInclude() []string {
+	patterns := []string{}
+	for _, extension := range helpers.Extensions() {
+		patterns = append(patterns, "*"+extension)
+	}
+	return patterns
+}
+
+// resolveTargets expands every target input into the list of inputs to validate.
+// Directories are walked recursively, globs are expanded and anything else, like StdinInput, is kept as is,
+// to be read later as a file path or raw data. Inputs prefixed with FilePrefix must name files,
+// directories or globs matching files.
+func resolveTargets(inputs, include, exclude []string) ([]string, error) {
+	if len(include) == 0 {
+		include = DefaultInclude
+	}
+	resolved := []string{}
+	seen := map[string]bool{}
+	add := func(inputs ...string) {
+		for _, input := range inputs {
+			if !seen[input] {
+				seen[input] = true
+				resolved = append(resolved, input)
+			}
+		}
+	}
+	for _, input := range inputs {
+		if input == StdinInput {
+			add(input)
+			continue
+		}
+		input, isPath := strings.CutPrefix(input, FilePrefix)
+		if info, err := os.Stat(input); err == nil {
+			if !info.IsDir() {
+				add(filepath.Clean(input))
+				continue
+			}
+			files, err := walkTargetDir(input, include, exclude)
+			if err != nil {
+				return nil, errors.Errorf("Error walking directory '%s': %v", input, err)
+			}
+			add(files...)
+			continue
+		}
+		if isGlob(input) {
+			matches, err := doublestar.FilepathGlob(input, doublestar.WithFilesOnly())
+			if err == nil && len(matches) > 0 {
+				sort.Strings(matches)
+				for _, match := range matches {
+					if !matchesAny(exclude, match) {
+						add(match)
+					}
+				}
+				continue
+			}
+		}
+		if isPath {
+			return nil, errors.Errorf("no file, directory or glob match found for '%s'", input)
+		}
+		add(input)
+	}
+	if len(resolved) == 0 {
+		return nil, errors.New("no targets found")
+	}
+	return resolved, nil
+}
+
+func walkTargetDir(root string, include, exclude []string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && matchesAny(exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchesAny(include, rel) && !matchesAny(exclude, rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// matchesAny reports whether the path matches one of the patterns. Patterns without a
+// separator are matched against the base name, so "*.yaml" matches at any depth.
+func matchesAny(patterns []string, p string) bool {
+	p = filepath.ToSlash(p)
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		name := p
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(p)
+		}
+		if matched, _ := doublestar.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isGlob(input string) bool {
+	return !strings.Contains(input, "\n") && strings.ContainsAny(input, "*?[{")
+}
+
+// pairOldTargets exposes the old version of every target document as oldObject, null when there's none, as when
+// a document is created. Documents are paired by kind, namespace and name when they all have a name, and by their
+// order otherwise, in which case there must be as many old documents as new ones.
+func pairOldTargets(targets, oldTargets []*models.TargetData) error {
+	for _, target := range targets {
+		target.Data["oldObject"] = nil
+	}
+	if len(oldTargets) == 0 {
+		return nil
+	}
+	if !allNamed(targets) || !allNamed(oldTargets) {
+		if len(targets) != len(oldTargets) {
+			return errors.Errorf("Error pairing old targets: documents without a name are paired by order, but %d old documents were given for %d documents", len(oldTargets), len(targets))
+		}
+		for i, target := range targets {
+			target.Data["oldObject"] = oldTargets[i].Data["object"]
+		}
+		return nil
+	}
+	oldObjects := map[string]interface{}{}
+	for _, oldTarget := range oldTargets {
+		key := documentKey(oldTarget)
+		if _, ok := oldObjects[key]; ok {
+			return errors.Errorf("Error pairing old targets: %s is defined more than once", key)
+		}
+		oldObjects[key] = oldTarget.Data["object"]
+	}
+	for _, target := range targets {
+		target.Data["oldObject"] = oldObjects[documentKey(target)]
+	}
+	return nil
+}
+
+func allNamed(targets []*models.TargetData) bool {
+	for _, target := range targets {
+		if target.Name == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// documentKey identifies a document by its kind, namespace and name
+func documentKey(target *models.TargetData) string {
+	namespace := helpers.LookupString(target.Data["object"], "metadata", "namespace")
+	if namespace == "" {
+		return fmt.Sprintf("%s %s", target.Kind, target.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", target.Kind, namespace, target.Name)
+}
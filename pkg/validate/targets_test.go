@@ -0,0 +1,202 @@
+package validate
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestResolveTargets(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"app/deployment.yaml",
+		"app/service.yml",
+		"app/config.json",
+		"app/README.md",
+		"app/testdata/broken.yaml",
+		"other/values.yaml",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Error creating directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("foo: bar\n"), 0o644); err != nil {
+			t.Fatalf("Error creating file: %v", err)
+		}
+	}
+	abs := func(paths ...string) []string {
+		result := []string{}
+		for _, path := range paths {
+			result = append(result, filepath.Join(root, path))
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		inputs   []string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "raw data is kept as is",
+			inputs:   []string{"foo: bar", `{"foo": ["bar"]}`},
+			expected: []string{"foo: bar", `{"foo": ["bar"]}`},
+		},
+		{
+			name:     "files are kept as is",
+			inputs:   abs("app/README.md"),
+			expected: abs("app/README.md"),
+		},
+		{
+			name:     "prefixed files are kept without their prefix",
+			inputs:   []string{FilePrefix + filepath.Join(root, "app/README.md")},
+			expected: abs("app/README.md"),
+		},
+		{
+			name:     "directories are walked with default include patterns",
+			inputs:   abs("app"),
+			expected: abs("app/config.json", "app/deployment.yaml", "app/service.yml", "app/testdata/broken.yaml"),
+		},
+		{
+			name:     "directories are walked with include and exclude patterns",
+			inputs:   abs("app"),
+			include:  []string{"*.yaml"},
+			exclude:  []string{"testdata"},
+			expected: abs("app/deployment.yaml"),
+		},
+		{
+			name:     "globs are expanded",
+			inputs:   []string{filepath.Join(root, "**", "*.yaml")},
+			exclude:  []string{"**/testdata/**"},
+			expected: abs("app/deployment.yaml", "other/values.yaml"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := resolveTargets(tc.inputs, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatalf("Error resolving targets: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestResolveTargetsDefaultInclude(t *testing.T) {
+	testCases := map[string][]string{
+		helpers.FormatJSON: {"config.json"},
+		helpers.FormatYAML: {"deployment.yaml", "service.yml"},
+		helpers.FormatTOML: {"Cargo.toml"},
+		helpers.FormatHCL:  {"main.tf", "prod.tfvars", "terragrunt.hcl"},
+		helpers.FormatXML:  {"App.csproj", "pom.xml"},
+	}
+	for format, files := range testCases {
+		t.Run(format, func(t *testing.T) {
+			root := t.TempDir()
+			expected := []string{}
+			for _, file := range append([]string{"README.md"}, files...) {
+				path := filepath.Join(root, "src", file)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatalf("Error creating directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte{}, 0o644); err != nil {
+					t.Fatalf("Error creating file: %v", err)
+				}
+				if file != "README.md" {
+					expected = append(expected, path)
+				}
+			}
+			sort.Strings(expected)
+			actual, err := resolveTargets([]string{root}, nil, nil)
+			if err != nil {
+				t.Fatalf("Error resolving targets: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestResolveTargetsMissingPath(t *testing.T) {
+	if _, err := resolveTargets([]string{FilePrefix + filepath.Join(t.TempDir(), "missing.yaml")}, nil, nil); err == nil {
+		t.Errorf("Expected an error for a prefixed path that doesn't exist, got none")
+	}
+}
+
+func TestPairOldTargets(t *testing.T) {
+	target := func(kind, namespace, name string, replicas int) *models.TargetData {
+		object := map[string]interface{}{"kind": kind, "spec": map[string]interface{}{"replicas": replicas}}
+		if name != "" {
+			object["metadata"] = map[string]interface{}{"name": name, "namespace": namespace}
+		}
+		return &models.TargetData{Data: map[string]interface{}{"object": object}, Kind: kind, Name: name}
+	}
+	testCases := []struct {
+		name          string
+		targets       []*models.TargetData
+		oldTargets    []*models.TargetData
+		expected      []interface{}
+		errorExpected bool
+	}{
+		{
+			name:     "no old targets",
+			targets:  []*models.TargetData{target("Deployment", "", "a", 1)},
+			expected: []interface{}{nil},
+		},
+		{
+			name:       "paired by kind, namespace and name",
+			targets:    []*models.TargetData{target("Deployment", "dev", "a", 1), target("Deployment", "prd", "a", 2), target("Service", "dev", "a", 3)},
+			oldTargets: []*models.TargetData{target("Deployment", "prd", "a", 4), target("Deployment", "dev", "a", 5)},
+			expected:   []interface{}{5, 4, nil},
+		},
+		{
+			name:       "paired by order",
+			targets:    []*models.TargetData{target("", "", "", 1), target("", "", "", 2)},
+			oldTargets: []*models.TargetData{target("", "", "", 3), target("", "", "", 4)},
+			expected:   []interface{}{3, 4},
+		},
+		{
+			name:          "unnamed documents count mismatch",
+			targets:       []*models.TargetData{target("", "", "", 1), target("", "", "", 2)},
+			oldTargets:    []*models.TargetData{target("", "", "", 3)},
+			errorExpected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pairOldTargets(tc.targets, tc.oldTargets)
+			if err != nil {
+				if !tc.errorExpected {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if tc.errorExpected {
+				t.Fatalf("Expected error, got none")
+			}
+			for i, target := range tc.targets {
+				oldObject, ok := target.Data["oldObject"]
+				if !ok {
+					t.Fatalf("Expected target %d to have an oldObject", i)
+				}
+				var replicas interface{}
+				if oldObject != nil {
+					replicas = oldObject.(map[string]interface{})["spec"].(map[string]interface{})["replicas"]
+				}
+				if replicas != tc.expected[i] {
+					t.Errorf("Expected target %d old replicas to be %v, got %v", i, tc.expected[i], replicas)
+				}
+			}
+		})
+	}
+}
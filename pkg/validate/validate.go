@@ -1,12 +1,17 @@
 package validate
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"celify/pkg/evaluator"
 	"celify/pkg/helpers"
 	"celify/pkg/printer"
+	"celify/pkg/profiles"
+	"celify/pkg/vap"
 
 	"celify/pkg/models"
 
@@ -14,87 +19,344 @@ import (
 	"github.com/pkg/errors"
 )
 
-func ValidateSingleExpression(expression, targetInput string, supressObjects bool) error {
-	targetData, err := readTarget(targetInput)
+// Options tweaks how targets are collected and how results are reported
+type Options struct {
+	SupressObjects bool
+	// Explain adds the evaluation trace of every sub-expression to failed validations
+	Explain bool
+	// Concurrency is the number of workers evaluating targets, defaulting to the number of CPUs
+	Concurrency int
+	// Include and Exclude filter the files picked when walking target directories
+	Include []string
+	Exclude []string
+	// Output is the format results are printed in, one of printer.Outputs
+	Output string
+	// OutputFile is the file results are written to instead of stdout
+	OutputFile string
+	// TargetFormat and ValidationsFormat are the format of the targets and validations, one of helpers.Formats,
+	// detected from the file extension or the data itself when empty
+	TargetFormat      string
+	ValidationsFormat string
+	// SplitLists validates every element of target documents that are lists as a document of its own
+	SplitLists bool
+	// OldTargets are the previous versions of the targets, exposed to expressions as oldObject
+	OldTargets []string
+	// Params is the path to, or the raw data of, the parameter object exposed to expressions as params
+	Params string
+	// Profile is the name of the profile tailoring expressions to the targets, one of profiles.Names()
+	Profile string
+	// FailOn is the least severe severity making the validation fail, or FailOnNone, defaulting to errors
+	FailOn string
+	// stdinData is the data read from stdin for the input given as StdinInput
+	stdinData []byte
+}
+
+// FailOnNone reports failed validations without ever failing
+const FailOnNone = "none"
+
+// StdinInput is the input read from stdin when given as a target, old target, validations or params
+const StdinInput = "-"
+
+// stdin is where StdinInput is read from
+var stdin io.Reader = os.Stdin
+
+func ValidateSingleExpression(expression string, targetInputs []string, opts Options) error {
+	opts, err := withStdin("", targetInputs, opts)
 	if err != nil {
-		return errors.Errorf("Error reading target: %v", err)
+		return err
 	}
-
-	eval, err := evaluator.NewEvaluator(targetData)
+	targets, err := readTargets(targetInputs, opts)
 	if err != nil {
-		return errors.Errorf("Error creating evaluator: %v", err)
+		return err
 	}
-	result := eval.EvaluateRule(models.ValidationRule{Expression: expression})
-	printer := printer.NewPrinter(eval)
-	printer.PrintResults([]models.EvaluationResult{result}, supressObjects)
-	return getErrors([]models.EvaluationResult{result})
+
+	validations := models.ValidationConfig{Validations: []models.ValidationRule{{Expression: expression}}}
+	return validateTargets(validations, targets, opts)
 }
 
-func Validate(validationInput, targetInput string, supressObjects bool) error {
+func Validate(validationInput string, targetInputs []string, opts Options) error {
+	opts, err := withStdin(validationInput, targetInputs, opts)
+	if err != nil {
+		return err
+	}
+
 	// Load validation rules
-	var validations models.ValidationConfig
-	_, err := unmarshalData(validationInput, &validations)
+	validations, err := readValidations(validationInput, opts)
 	if err != nil {
 		return errors.Errorf("Error reading validations: %v", err)
 	}
 
-	// Load target YAML data
-	targetData, err := readTarget(targetInput)
+	// Load target documents
+	targets, err := readTargets(targetInputs, opts)
 	if err != nil {
-		return errors.Errorf("Error reading target: %v", err)
+		return err
 	}
 
-	eval, err := evaluator.NewEvaluator(targetData)
+	return validateTargets(validations, targets, opts)
+}
+
+func validateTargets(validations models.ValidationConfig, targets []*models.TargetData, opts Options) error {
+	failOn, err := failOnThreshold(opts.FailOn)
+	if err != nil {
+		return err
+	}
+	var writer io.Writer = os.Stdout
+	if opts.OutputFile != "" {
+		file, err := os.Create(opts.OutputFile)
+		if err != nil {
+			return errors.Errorf("Error creating output file: %v", err)
+		}
+		defer file.Close()
+		writer = printer.NoColor(file)
+	}
+	resultPrinter, err := printer.NewPrinter(opts.Output, writer)
+	if err != nil {
+		return err
+	}
+	runner, err := evaluator.NewRunner(opts.Concurrency)
 	if err != nil {
 		return errors.Errorf("Error creating evaluator: %v", err)
 	}
-	results := eval.Evaluate(validations)
-	printer := printer.NewPrinter(eval)
-	printer.PrintResults(results, supressObjects)
-	return getErrors(results)
+	runner.Evaluator.Explain = opts.Explain
+	runner.Evaluator.Params = helpers.Normalize(validations.Params)
+	if opts.Params != "" {
+		params, err := readParams(opts.Params, opts)
+		if err != nil {
+			return errors.Errorf("Error reading params: %v", err)
+		}
+		runner.Evaluator.Params = params
+	}
+	if opts.Profile != "" {
+		profile, err := profiles.Get(opts.Profile)
+		if err != nil {
+			return err
+		}
+		if err := runner.Evaluator.UseProfile(profile); err != nil {
+			return errors.Errorf("Error creating evaluator: %v", err)
+		}
+	}
+	ruleSet, err := runner.Compile(validations)
+	if err != nil {
+		return fmtError(errors.Errorf("Error compiling validations: %v", err), resultPrinter.Output)
+	}
+	targetResults := runner.Run(ruleSet, targets)
+	if err := resultPrinter.PrintResults(targetResults, opts.SupressObjects); err != nil {
+		return errors.Errorf("Error printing results: %v", err)
+	}
+	return getErrors(targetResults, resultPrinter.Output, failOn)
 }
 
-func unmarshalData(input string, output interface{}) (string, error) {
-	//convert input to a byte slice
-	configData := []byte(input)
+// withStdin reads stdin when an input is StdinInput, keeping its data to be read as inline data, never as a path.
+// Stdin can only be read once, so only one input can be StdinInput.
+func withStdin(validationInput string, targetInputs []string, opts Options) (Options, error) {
+	inputs := append([]string{validationInput, opts.Params}, targetInputs...)
+	inputs = append(inputs, opts.OldTargets...)
+	count := 0
+	for _, input := range inputs {
+		if input == StdinInput {
+			count++
+		}
+	}
+	if count == 0 {
+		return opts, nil
+	}
+	if count > 1 {
+		return opts, errors.Errorf("Only one of the targets, old targets, validations and params can be read from stdin")
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return opts, errors.Errorf("Error reading stdin: %v", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return opts, errors.Errorf("Error reading stdin: no data")
+	}
+	opts.stdinData = data
+	return opts, nil
+}
 
-	format, err := helpers.UnmarshalData(configData, output)
+// failOnThreshold validates the --fail-on severity, returning the number of severities, from the most severe,
+// that make the validation fail
+func failOnThreshold(failOn string) (int, error) {
+	if failOn == "" {
+		failOn = models.SeverityError
+	}
+	if failOn == FailOnNone {
+		return 0, nil
+	}
+	for i, severity := range models.Severities {
+		if failOn == severity {
+			return i + 1, nil
+		}
+	}
+	return 0, errors.Errorf("Invalid fail-on '%s' provided, expected one of: %s, %s", failOn, strings.Join(models.Severities, ", "), FailOnNone)
+}
+
+// failsOn tells whether a failed validation of the given severity is within the fail-on threshold
+func failsOn(severity string, threshold int) bool {
+	for _, failing := range models.Severities[:threshold] {
+		if severity == failing {
+			return true
+		}
+	}
+	return false
+}
+
+// readTargets reads every target document, along with the old version of each document when old targets are given
+func readTargets(targetInputs []string, opts Options) ([]*models.TargetData, error) {
+	targets, err := readTargetInputs(targetInputs, opts)
 	if err != nil {
-		configData, err := os.ReadFile(input)
+		return nil, err
+	}
+	oldTargets := []*models.TargetData{}
+	if len(opts.OldTargets) > 0 {
+		oldTargets, err = readTargetInputs(opts.OldTargets, opts)
 		if err != nil {
-			return "", errors.Errorf("Error reading data: %v", err)
+			return nil, errors.Errorf("Error reading old target: %v", err)
 		}
-		format, err = helpers.UnmarshalData(configData, output)
+	}
+	if err := pairOldTargets(targets, oldTargets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func readTargetInputs(targetInputs []string, opts Options) ([]*models.TargetData, error) {
+	inputs, err := resolveTargets(targetInputs, opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, errors.Errorf("Error reading target: %v", err)
+	}
+	targets := []*models.TargetData{}
+	for _, input := range inputs {
+		inputTargets, err := readTarget(input, opts)
 		if err != nil {
-			return "", errors.Errorf("Error parsing validations YAML: %v", err)
+			return nil, errors.Errorf("Error reading target: %v", err)
 		}
+		targets = append(targets, inputTargets...)
+	}
+	return targets, nil
+}
+
+// readValidations reads a validations file or inline data, translating it when it holds ValidatingAdmissionPolicies
+func readValidations(raw string, opts Options) (models.ValidationConfig, error) {
+	var validations models.ValidationConfig
+	in, err := readInput(raw, opts.ValidationsFormat, opts.stdinData)
+	if err != nil {
+		return validations, err
+	}
+	docs, format, err := helpers.UnmarshalDocumentsAs(in.data, in.format)
+	if err != nil {
+		return validations, in.parseError("validations", err)
+	}
+	if err := in.notFound(docs, format); err != nil {
+		return validations, err
 	}
+	if vap.ContainsPolicy(docs) {
+		return vap.ToValidationConfig(docs)
+	}
+	if _, err := helpers.UnmarshalDataAs(in.data, format, &validations); err != nil {
+		return validations, in.parseError("validations", err)
+	}
+	return validations, nil
+}
 
-	return format, nil
+// readParams reads the parameter object from a file or, when no such file exists, from inline data
+func readParams(raw string, opts Options) (interface{}, error) {
+	in, err := readInput(raw, "", opts.stdinData)
+	if err != nil {
+		return nil, err
+	}
+	var params interface{}
+	format, err := helpers.UnmarshalDataAs(in.data, in.format, &params)
+	if err != nil {
+		return nil, in.parseError("params", err)
+	}
+	if err := in.notFound([]interface{}{params}, format); err != nil {
+		return nil, err
+	}
+	return helpers.Normalize(params), nil
 }
 
-func readTarget(input string) (*models.TargetData, error) {
-	var targetObject map[string]interface{}
-	var format string
-	format, err := unmarshalData(input, &targetObject)
+// readTarget reads the documents of a target file or, when no such file exists, of inline data.
+// With SplitLists, every document that is a list is replaced by its elements.
+func readTarget(raw string, opts Options) ([]*models.TargetData, error) {
+	in, err := readInput(raw, opts.TargetFormat, opts.stdinData)
 	if err != nil {
-		return nil, errors.Errorf("Error parsing target data: %v", err)
+		return nil, err
+	}
+	docs, format, err := helpers.UnmarshalDocumentsAs(in.data, in.format)
+	if err != nil {
+		return nil, in.parseError("target data", err)
+	}
+	if err := in.notFound(docs, format); err != nil {
+		return nil, err
 	}
-	return &models.TargetData{
-		Data:   map[string]interface{}{"object": targetObject},
-		Format: format,
-	}, nil
+	var positions []map[string]models.Position
+	switch format {
+	case helpers.FormatJSON, helpers.FormatYAML:
+		positions = helpers.DocumentPositions(in.data, in.source)
+	case helpers.FormatHCL:
+		positions = helpers.HCLPositions(in.data, in.source)
+	case helpers.FormatXML:
+		positions = helpers.XMLPositions(in.data, in.source)
+	}
+	if len(positions) != len(docs) {
+		positions = make([]map[string]models.Position, len(docs))
+	}
+	targets := []*models.TargetData{}
+	add := func(doc interface{}, docPositions map[string]models.Position) {
+		targets = append(targets, &models.TargetData{
+			Data:      map[string]interface{}{"object": doc},
+			Format:    format,
+			Source:    in.source,
+			Document:  len(targets),
+			Kind:      helpers.LookupString(doc, "kind"),
+			Name:      helpers.LookupString(doc, "metadata", "name"),
+			Positions: docPositions,
+		})
+	}
+	for i, doc := range docs {
+		elements, isList := doc.([]interface{})
+		if !opts.SplitLists || !isList {
+			add(doc, positions[i])
+			continue
+		}
+		for j, element := range elements {
+			add(element, helpers.SubPositions(positions[i], fmt.Sprintf("[%d]", j)))
+		}
+	}
+	return targets, nil
 }
 
-func getErrors(results []models.EvaluationResult) error {
+// fmtError colours the error summary of text output, leaving other outputs free of anything but results
+func fmtError(err error, output string) error {
+	if output != printer.OutputText {
+		return err
+	}
+	return printer.FmtError(err)
+}
+
+func getErrors(targetResults []models.TargetResult, output string, failOn int) error {
 	multiErr := &multierror.Error{Errors: []error{}}
-	for _, result := range results {
-		if result.ValidationError != nil {
-			multiErr.Errors = append(multiErr.Errors, fmt.Errorf("expression: %s\n\t  error: %v", result.Expression, result.ValidationError))
+	for _, targetResult := range targetResults {
+		for _, result := range targetResult.Results {
+			if result.ValidationError == nil || !failsOn(result.Rule.EffectiveSeverity(), failOn) {
+				continue
+			}
+			description := fmt.Sprintf("expression: %s\n\t  error: %v", result.Expression, result.ValidationError)
+			if result.Rule.ID != "" || result.Rule.Name != "" {
+				description = fmt.Sprintf("rule: %s\n\t  %s", printer.RuleLabel(result), description)
+			}
+			if len(targetResults) > 1 {
+				description = fmt.Sprintf("%s\n\t  %s", printer.TargetLabel(targetResult.Target), description)
+			}
+			multiErr.Errors = append(multiErr.Errors, errors.New(description))
 		}
 	}
 	if len(multiErr.Errors) == 0 {
 		return nil
 	}
+	if output != printer.OutputText {
+		return errors.Errorf("%d validations failed", len(multiErr.Errors))
+	}
 	return printer.FmtError(multiErr.ErrorOrNil())
 }
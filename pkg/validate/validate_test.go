@@ -4,6 +4,12 @@ import (
 	"celify/pkg/helpers"
 	"celify/pkg/models"
 	"celify/pkg/printer"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-multierror"
@@ -13,17 +19,19 @@ import (
 func TestReadTarget(t *testing.T) {
 	testCases := []struct {
 		input    string
-		expected *models.TargetData
+		expected []*models.TargetData
 	}{
 		{
 			input: `foo: bar`,
-			expected: &models.TargetData{
-				Data: map[string]interface{}{
-					"object": map[string]interface{}{
-						"foo": "bar",
+			expected: []*models.TargetData{
+				{
+					Data: map[string]interface{}{
+						"object": map[string]interface{}{
+							"foo": "bar",
+						},
 					},
+					Format: "yaml",
 				},
-				Format: "yaml",
 			},
 		},
 		{
@@ -32,26 +40,76 @@ func TestReadTarget(t *testing.T) {
 		"foo": "bar"
 	}
 }`,
-			expected: &models.TargetData{
-				Data: map[string]interface{}{
-					"object": map[string]interface{}{
-						"contoso": map[string]interface{}{
-							"foo": "bar",
+			expected: []*models.TargetData{
+				{
+					Data: map[string]interface{}{
+						"object": map[string]interface{}{
+							"contoso": map[string]interface{}{
+								"foo": "bar",
+							},
 						},
 					},
+					Format: "json",
+				},
+			},
+		},
+		{
+			input: `---
+kind: ConfigMap
+metadata:
+  name: first
+---
+---
+kind: Secret
+metadata:
+  name: second
+`,
+			expected: []*models.TargetData{
+				{
+					Data: map[string]interface{}{
+						"object": map[string]interface{}{
+							"kind":     "ConfigMap",
+							"metadata": map[string]interface{}{"name": "first"},
+						},
+					},
+					Format:   "yaml",
+					Document: 0,
+					Kind:     "ConfigMap",
+					Name:     "first",
+				},
+				{
+					Data: map[string]interface{}{
+						"object": map[string]interface{}{
+							"kind":     "Secret",
+							"metadata": map[string]interface{}{"name": "second"},
+						},
+					},
+					Format:   "yaml",
+					Document: 1,
+					Kind:     "Secret",
+					Name:     "second",
 				},
-				Format: "json",
 			},
 		},
 	}
 	for _, tc := range testCases {
-		targetData, err := readTarget(tc.input)
+		targets, err := readTarget(tc.input, Options{})
 		if err != nil {
 			t.Errorf("Error reading target data: %v", err)
 			t.FailNow()
 		}
-		if !helpers.CompareInterfaces(targetData, tc.expected) {
-			t.Errorf("Expected %v, got %v", tc.expected, targetData)
+		if len(targets) != len(tc.expected) {
+			t.Errorf("Expected %d documents, got %d", len(tc.expected), len(targets))
+			continue
+		}
+		for i, target := range targets {
+			expected := tc.expected[i]
+			if !helpers.CompareInterfaces(target, expected) {
+				t.Errorf("Expected %v, got %v", expected, target)
+			}
+			if target.Format != expected.Format || target.Document != expected.Document || target.Kind != expected.Kind || target.Name != expected.Name {
+				t.Errorf("Expected %+v, got %+v", expected, target)
+			}
 		}
 	}
 }
@@ -103,11 +161,21 @@ foo: baz
 `,
 		expectedError: nil,
 	},
+	{
+		validations: `validations:
+- expression: "object.foo == 'bar'"
+`,
+		target: `foo: bar
+---
+foo: baz
+`,
+		expectedError: &multierror.Error{Errors: []error{printer.FmtError(errors.Errorf("document 1\n\t  expression: object.foo == 'bar'\n\t  error: message: validation failed"))}},
+	},
 }
 
 func TestValidateWithRawData(t *testing.T) {
 	for _, tc := range validateTestCases {
-		err := Validate(tc.validations, tc.target, true)
+		err := Validate(tc.validations, []string{tc.target}, Options{SupressObjects: true})
 		if err != nil && tc.expectedError == nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -126,7 +194,7 @@ func TestValidateWithFiles(t *testing.T) {
 			t.Errorf("Error creating target file: %v", err)
 			t.FailNow()
 		}
-		err = Validate(validationsFile.Name(), targetFile.Name(), true)
+		err = Validate(validationsFile.Name(), []string{targetFile.Name()}, Options{SupressObjects: true})
 		if err != nil && tc.expectedError == nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -167,7 +235,7 @@ foo: baz
 
 func TestValidateSingleExpressionWithRawData(t *testing.T) {
 	for _, tc := range validateSingleExpressionTestCases {
-		err := ValidateSingleExpression(tc.expression, tc.target, true)
+		err := ValidateSingleExpression(tc.expression, []string{tc.target}, Options{SupressObjects: true})
 		if err != nil && !tc.errorExpected {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -184,7 +252,7 @@ func TestValidateSingleExpressionWithFiles(t *testing.T) {
 			t.Errorf("Error creating target file: %v", err)
 			t.FailNow()
 		}
-		err = ValidateSingleExpression(tc.expression, targetFile.Name(), true)
+		err = ValidateSingleExpression(tc.expression, []string{targetFile.Name()}, Options{SupressObjects: true})
 		if err != nil && !tc.errorExpected {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -193,3 +261,349 @@ func TestValidateSingleExpressionWithFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateFailOn(t *testing.T) {
+	validations := `validations:
+- expression: "object.foo == 'bar'"
+  severity: warning
+- expression: "object.enabled"
+  severity: info
+`
+	testCases := []struct {
+		failOn        string
+		errorExpected bool
+	}{
+		{failOn: "", errorExpected: false},
+		{failOn: "error", errorExpected: false},
+		{failOn: "warning", errorExpected: true},
+		{failOn: "info", errorExpected: true},
+		{failOn: "none", errorExpected: false},
+		{failOn: "critical", errorExpected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.failOn, func(t *testing.T) {
+			err := Validate(validations, []string{"{\"foo\": \"baz\", \"enabled\": false}"}, Options{SupressObjects: true, FailOn: tc.failOn})
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestFailsOn(t *testing.T) {
+	threshold, err := failOnThreshold("warning")
+	if err != nil {
+		t.Fatalf("Error parsing fail-on: %v", err)
+	}
+	for severity, expected := range map[string]bool{"error": true, "warning": true, "info": false} {
+		if actual := failsOn(severity, threshold); actual != expected {
+			t.Errorf("Expected failsOn(%s) to be %v, got %v", severity, expected, actual)
+		}
+	}
+}
+
+func TestValidateWithParams(t *testing.T) {
+	paramsFile, err := helpers.CreateTempFile("minReplicas: 3\n")
+	if err != nil {
+		t.Fatalf("Error creating params file: %v", err)
+	}
+	testCases := []struct {
+		params        string
+		errorExpected bool
+	}{
+		{params: paramsFile.Name(), errorExpected: true},
+		{params: `{"minReplicas": 2}`, errorExpected: false},
+		{params: "minReplicas: 1", errorExpected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.params, func(t *testing.T) {
+			err := ValidateSingleExpression("object.replicas >= params.minReplicas", []string{"replicas: 2"}, Options{SupressObjects: true, Params: tc.params})
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestValidateWithProfile(t *testing.T) {
+	plan := `{"resource_changes": [{"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "change": {"actions": ["delete"], "before": {"bucket": "logs"}, "after": null}}]}`
+	testCases := []struct {
+		name          string
+		profile       string
+		expression    string
+		errorExpected bool
+	}{
+		{name: "passing", profile: "terraform-plan", expression: "resourcesByType('aws_s3_bucket').all(b, b.before.bucket == 'logs')"},
+		{name: "failing", profile: "terraform-plan", expression: "changes.deletes.size() == 0", errorExpected: true},
+		{name: "no profile", expression: "changes.deletes.size() == 0", errorExpected: true},
+		{name: "invalid profile", profile: "helm", expression: "true", errorExpected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSingleExpression(tc.expression, []string{plan}, Options{SupressObjects: true, Profile: tc.profile})
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestReadTargetListRoots(t *testing.T) {
+	input := `[{"name": "a"}, {"name": "b"}]`
+	targets, err := readTarget(input, Options{})
+	if err != nil {
+		t.Fatalf("Error reading target data: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("Expected 1 document, got %d", len(targets))
+	}
+	if _, ok := targets[0].Data["object"].([]interface{}); !ok {
+		t.Errorf("Expected the object to be a list, got %T", targets[0].Data["object"])
+	}
+
+	targets, err = readTarget(input, Options{SplitLists: true})
+	if err != nil {
+		t.Fatalf("Error reading target data: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(targets))
+	}
+	for i, name := range []string{"a", "b"} {
+		if targets[i].Document != i || helpers.LookupString(targets[i].Data["object"], "name") != name {
+			t.Errorf("Expected document %d to be named %s, got %+v", i, name, targets[i])
+		}
+	}
+}
+
+func TestValidateNonMapRoots(t *testing.T) {
+	testCases := []struct {
+		expression    string
+		target        string
+		errorExpected bool
+	}{
+		{expression: "object.all(c, c.image.startsWith('team-a'))", target: `[{"image": "team-a/app"}]`, errorExpected: false},
+		{expression: "size(object) == 2", target: "- a\n- b\n", errorExpected: false},
+		{expression: "object > 40", target: "42", errorExpected: false},
+		{expression: "object == 'foo'", target: `"foo"`, errorExpected: false},
+		{expression: "true", target: "missing.yaml", errorExpected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.target, func(t *testing.T) {
+			err := ValidateSingleExpression(tc.expression, []string{tc.target}, Options{SupressObjects: true})
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestValidateWithStdin(t *testing.T) {
+	validations := `validations:
+- expression: "object.replicas >= 2"
+`
+	testCases := []struct {
+		name          string
+		stdin         string
+		validations   string
+		targets       []string
+		opts          Options
+		errorExpected bool
+	}{
+		{name: "target", stdin: `{"replicas": 2}`, validations: validations, targets: []string{StdinInput}},
+		{name: "failing target", stdin: "replicas: 1", validations: validations, targets: []string{StdinInput}, errorExpected: true},
+		{name: "validations", stdin: validations, validations: StdinInput, targets: []string{"replicas: 2"}},
+		{name: "params", stdin: "minReplicas: 3", validations: `validations:
+- expression: "object.replicas >= params.minReplicas"
+`, targets: []string{"replicas: 2"}, opts: Options{Params: StdinInput}, errorExpected: true},
+		{name: "empty", stdin: "\n", validations: validations, targets: []string{StdinInput}, errorExpected: true},
+		{name: "read twice", stdin: "replicas: 2", validations: StdinInput, targets: []string{StdinInput}, errorExpected: true},
+	}
+	defer func(original io.Reader) { stdin = original }(stdin)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdin = strings.NewReader(tc.stdin)
+			tc.opts.SupressObjects = true
+			err := Validate(tc.validations, tc.targets, tc.opts)
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestStdinIsInlineData(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.yaml")
+	if err := os.WriteFile(file, []byte("replicas: 2\n"), 0o644); err != nil {
+		t.Fatalf("Error creating target file: %v", err)
+	}
+	defer func(original io.Reader) { stdin = original }(stdin)
+	for _, data := range []string{file, "@foo", FilePrefix + file, "*.yaml", filepath.Join(dir, "*.yaml")} {
+		t.Run(data, func(t *testing.T) {
+			stdin = strings.NewReader(data)
+			opts, err := withStdin("", []string{StdinInput}, Options{})
+			if err != nil {
+				t.Fatalf("Error reading stdin: %v", err)
+			}
+			inputs, err := resolveTargets([]string{StdinInput}, nil, nil)
+			if err != nil || !reflect.DeepEqual(inputs, []string{StdinInput}) {
+				t.Fatalf("Expected stdin to be kept as is, got %v, %v", inputs, err)
+			}
+			in, err := readInput(StdinInput, "", opts.stdinData)
+			if err != nil {
+				t.Fatalf("Error reading input: %v", err)
+			}
+			if string(in.data) != data || in.source != "" {
+				t.Errorf("Expected inline data '%s', got '%s' from '%s'", data, in.data, in.source)
+			}
+		})
+	}
+
+	stdin = strings.NewReader(file)
+	if err := ValidateSingleExpression(fmt.Sprintf("object == '%s'", file), []string{StdinInput}, Options{SupressObjects: true}); err != nil {
+		t.Errorf("Expected stdin naming a file to be validated as a string, got %v", err)
+	}
+}
+
+func TestReadInputErrors(t *testing.T) {
+	jsonFile, err := helpers.CreateTempFile("{\n  \"foo\": \"bar\",\n}\n")
+	if err != nil {
+		t.Fatalf("Error creating target file: %v", err)
+	}
+	jsonPath := jsonFile.Name() + ".json"
+	if err := os.Rename(jsonFile.Name(), jsonPath); err != nil {
+		t.Fatalf("Error renaming target file: %v", err)
+	}
+	testCases := []struct {
+		name          string
+		input         string
+		opts          Options
+		expectedError string
+	}{
+		{name: "yaml typo", input: "foo: bar\n  baz: qux\n", expectedError: "Error parsing target data: Error unmarshalling target: yaml: line 1: mapping values"},
+		{name: "explicit format", input: "foo: bar", opts: Options{TargetFormat: "json"}, expectedError: "json: line 1, column 2"},
+		{name: "invalid format", input: "foo: bar", opts: Options{TargetFormat: "ini"}, expectedError: "Invalid format 'ini'"},
+		{name: "file extension", input: jsonPath, expectedError: fmt.Sprintf("Error parsing target data '%s': Error unmarshalling target: json: line 3, column 1", jsonPath)},
+		{name: "missing file", input: "missing.yaml", expectedError: "no such file or directory"},
+		{name: "missing prefixed file", input: FilePrefix + "missing.yaml", expectedError: "no such file or directory"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readTarget(tc.input, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing '%s', got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	_, err = readValidations("validations:\n- expression: \"true\"\n  message: [\n", Options{})
+	if err == nil || !strings.Contains(err.Error(), "Error parsing validations: Error unmarshalling target: yaml: line 3") {
+		t.Errorf("Expected a validations parse error with its line, got %v", err)
+	}
+}
+
+func TestValidateFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		file          string
+		data          string
+		format        string
+		expression    string
+		errorExpected bool
+	}{
+		{
+			file: "Cargo.toml",
+			data: `[package]
+name = "celify"
+edition = "2021"
+
+[[bin]]
+name = "a"
+path = "src/a.rs"
+`,
+			format:     helpers.FormatTOML,
+			expression: `object.package.edition == "2021" && object.bin.all(b, has(b.path))`,
+		},
+		{
+			file:          "pyproject.toml",
+			data:          "[project]\nname = \"celify\"\n",
+			format:        helpers.FormatTOML,
+			expression:    `object.project.name == "other"`,
+			errorExpected: true,
+		},
+		{
+			file: "main.tf",
+			data: `resource "aws_s3_bucket" "logs" {
+  bucket = "logs-${var.env}"
+  versioning {
+    enabled = true
+  }
+}
+`,
+			format:     helpers.FormatHCL,
+			expression: `object.resource.aws_s3_bucket.all(name, object.resource.aws_s3_bucket[name].all(b, has(b.versioning)))`,
+		},
+		{
+			file:          "prod.tfvars",
+			data:          "instance_type = \"t3.large\"\n",
+			format:        helpers.FormatHCL,
+			expression:    `object.instance_type.startsWith("t2.")`,
+			errorExpected: true,
+		},
+		{
+			file: "pom.xml",
+			data: `<project>
+  <dependencies>
+    <dependency scope="test"><artifactId>junit</artifactId></dependency>
+  </dependencies>
+</project>
+`,
+			format:     helpers.FormatXML,
+			expression: `object.project.dependencies[0].dependency.all(d, d["@scope"] == "test" && d.artifactId[0] == "junit")`,
+		},
+		{
+			file:          "App.csproj",
+			data:          "<Project Sdk=\"Microsoft.NET.Sdk\"><PropertyGroup><TargetFramework>net6.0</TargetFramework></PropertyGroup></Project>\n",
+			format:        helpers.FormatXML,
+			expression:    `object.Project.PropertyGroup.all(g, g.TargetFramework.all(f, f == "net8.0"))`,
+			errorExpected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			target := filepath.Join(dir, tc.file)
+			if err := os.WriteFile(target, []byte(tc.data), 0o644); err != nil {
+				t.Fatalf("Error creating target file: %v", err)
+			}
+			targets, err := readTarget(target, Options{})
+			if err != nil {
+				t.Fatalf("Error reading target: %v", err)
+			}
+			if len(targets) != 1 || targets[0].Format != tc.format {
+				t.Fatalf("Expected a single %s document, got %+v", tc.format, targets)
+			}
+			err = ValidateSingleExpression(tc.expression, []string{target}, Options{})
+			if err != nil && !tc.errorExpected {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err == nil && tc.errorExpected {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
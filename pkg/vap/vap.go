@@ -0,0 +1,232 @@
+// Package vap translates Kubernetes ValidatingAdmissionPolicy manifests, along with their bindings and params,
+// into validations, so the policies enforced in a cluster can be run against manifests before they get there
+package vap
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+	"github.com/pkg/errors"
+)
+
+const (
+	apiGroup    = "admissionregistration.k8s.io/"
+	policyKind  = "ValidatingAdmissionPolicy"
+	bindingKind = "ValidatingAdmissionPolicyBinding"
+)
+
+type resource struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+}
+
+type policy struct {
+	resource `yaml:",inline"`
+	Spec     struct {
+		ParamKind *struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+		} `yaml:"paramKind"`
+		MatchConditions []models.MatchCondition `yaml:"matchConditions"`
+		Variables       []models.Variable       `yaml:"variables"`
+		Validations     []struct {
+			Expression        string `yaml:"expression"`
+			Message           string `yaml:"message"`
+			MessageExpression string `yaml:"messageExpression"`
+			Reason            string `yaml:"reason"`
+		} `yaml:"validations"`
+	} `yaml:"spec"`
+}
+
+type binding struct {
+	resource `yaml:",inline"`
+	Spec     struct {
+		PolicyName string `yaml:"policyName"`
+		ParamRef   *struct {
+			Name      string      `yaml:"name"`
+			Namespace string      `yaml:"namespace"`
+			Selector  interface{} `yaml:"selector"`
+		} `yaml:"paramRef"`
+		ValidationActions []string `yaml:"validationActions"`
+	} `yaml:"spec"`
+}
+
+// ContainsPolicy tells whether any of the documents is a ValidatingAdmissionPolicy
+func ContainsPolicy(docs []interface{}) bool {
+	for _, doc := range expandLists(docs) {
+		if isAdmissionKind(doc, policyKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToValidationConfig translates every ValidatingAdmissionPolicy in the documents into validations:
+//   - every policy validation becomes a rule with the id <policy>/<index>, using its message as the
+//     message expression when it has no messageExpression
+//   - the policy matchConditions become the match conditions of each of its rules
+//   - variables are shared by all rules, so policies declaring the same variable must agree on its expression
+//   - the validationActions of the policy binding set the severity: Deny is an error, Warn a warning
+//     and Audit an info, policies without a binding being errors and bindings without actions invalid
+//   - the object named by the binding paramRef, of the policy paramKind, becomes the params, selectors
+//     not being supported
+//
+// matchConstraints, matchResources and failurePolicy are not translated, so match conditions are what
+// scope the rules to the targets they apply to.
+func ToValidationConfig(decoded []interface{}) (models.ValidationConfig, error) {
+	docs := expandLists(decoded)
+	config := models.ValidationConfig{}
+	bindings := map[string]binding{}
+	for _, doc := range docs {
+		if !isAdmissionKind(doc, bindingKind) {
+			continue
+		}
+		var b binding
+		if err := decode(doc, &b); err != nil {
+			return config, errors.Errorf("Error reading binding '%s': %v", helpers.LookupString(doc, "metadata", "name"), err)
+		}
+		if _, ok := bindings[b.Spec.PolicyName]; ok {
+			return config, errors.Errorf("Policy '%s' has more than one binding, only one binding per policy is supported", b.Spec.PolicyName)
+		}
+		bindings[b.Spec.PolicyName] = b
+	}
+	variables := map[string]string{}
+	paramsFrom := ""
+	for _, doc := range docs {
+		if !isAdmissionKind(doc, policyKind) {
+			continue
+		}
+		var p policy
+		if err := decode(doc, &p); err != nil {
+			return config, errors.Errorf("Error reading policy '%s': %v", helpers.LookupString(doc, "metadata", "name"), err)
+		}
+		name := p.Metadata.Name
+		for _, variable := range p.Spec.Variables {
+			if expression, ok := variables[variable.Name]; ok {
+				if expression != variable.Expression {
+					return config, errors.Errorf("Policy '%s' declares variable '%s' with a different expression than another policy", name, variable.Name)
+				}
+				continue
+			}
+			variables[variable.Name] = variable.Expression
+			config.Variables = append(config.Variables, variable)
+		}
+		b, bound := bindings[name]
+		severity := models.SeverityError
+		if bound {
+			var err error
+			severity, err = severityOf(b.Spec.ValidationActions)
+			if err != nil {
+				return config, errors.Errorf("Error reading binding '%s': %v", b.Metadata.Name, err)
+			}
+			if p.Spec.ParamKind != nil && b.Spec.ParamRef != nil {
+				if b.Spec.ParamRef.Selector != nil {
+					return config, errors.Errorf("Error reading binding '%s': paramRef.selector is not supported, name the params object with paramRef.name", b.Metadata.Name)
+				}
+				params, err := findParams(docs, p.Spec.ParamKind.Kind, b.Spec.ParamRef.Name, b.Spec.ParamRef.Namespace)
+				if err != nil {
+					return config, errors.Errorf("Error reading params of policy '%s': %v", name, err)
+				}
+				if paramsFrom != "" {
+					return config, errors.Errorf("Policies '%s' and '%s' both have params, only one params object is supported", paramsFrom, name)
+				}
+				config.Params = params
+				paramsFrom = name
+			}
+		}
+		for i, validation := range p.Spec.Validations {
+			rule := models.ValidationRule{
+				ID:                fmt.Sprintf("%s/%d", name, i+1),
+				Severity:          severity,
+				MatchConditions:   p.Spec.MatchConditions,
+				Expression:        validation.Expression,
+				MessageExpression: validation.MessageExpression,
+			}
+			if rule.MessageExpression == "" && validation.Message != "" {
+				rule.MessageExpression = strconv.Quote(validation.Message)
+			}
+			config.Validations = append(config.Validations, rule)
+		}
+	}
+	return config, nil
+}
+
+// severityOf maps the validation actions of a binding to the severity of the rules it binds, rejecting bindings
+// without actions or with unknown ones as Kubernetes does
+func severityOf(actions []string) (string, error) {
+	if len(actions) == 0 {
+		return "", errors.New("validationActions must not be empty")
+	}
+	severity := models.SeverityInfo
+	for _, action := range actions {
+		switch action {
+		case "Deny":
+			severity = models.SeverityError
+		case "Warn":
+			if severity != models.SeverityError {
+				severity = models.SeverityWarning
+			}
+		case "Audit":
+		default:
+			return "", errors.Errorf("unknown validation action '%s', expected one of: Deny, Warn, Audit", action)
+		}
+	}
+	return severity, nil
+}
+
+func findParams(docs []map[string]interface{}, kind, name, namespace string) (interface{}, error) {
+	for _, doc := range docs {
+		if helpers.LookupString(doc, "kind") != kind || helpers.LookupString(doc, "metadata", "name") != name {
+			continue
+		}
+		if namespace != "" && helpers.LookupString(doc, "metadata", "namespace") != namespace {
+			continue
+		}
+		return helpers.Normalize(doc), nil
+	}
+	return nil, errors.Errorf("no %s named '%s' found", kind, name)
+}
+
+func isAdmissionKind(doc map[string]interface{}, kind string) bool {
+	return helpers.LookupString(doc, "kind") == kind && strings.HasPrefix(helpers.LookupString(doc, "apiVersion"), apiGroup)
+}
+
+// expandLists returns the documents that are maps, replacing List documents, like the output of kubectl get -o yaml,
+// by their items
+func expandLists(docs []interface{}) []map[string]interface{} {
+	expanded := []map[string]interface{}{}
+	for _, decoded := range docs {
+		doc, ok := helpers.Normalize(decoded).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		items, ok := doc["items"].([]interface{})
+		if helpers.LookupString(doc, "kind") != "List" || !ok {
+			expanded = append(expanded, doc)
+			continue
+		}
+		for _, item := range items {
+			if itemDoc, ok := item.(map[string]interface{}); ok {
+				expanded = append(expanded, itemDoc)
+			}
+		}
+	}
+	return expanded
+}
+
+// decode converts a decoded document into the given struct
+func decode(doc map[string]interface{}, out interface{}) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
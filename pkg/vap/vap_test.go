@@ -0,0 +1,217 @@
+package vap
+
+import (
+	"celify/pkg/helpers"
+	"celify/pkg/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const manifests = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: replicas
+spec:
+  paramKind:
+    apiVersion: v1
+    kind: ConfigMap
+  matchConstraints:
+    resourceRules:
+    - apiGroups: ["apps"]
+      apiVersions: ["v1"]
+      operations: ["CREATE", "UPDATE"]
+      resources: ["deployments"]
+  matchConditions:
+  - name: is-deployment
+    expression: "object.kind == 'Deployment'"
+  variables:
+  - name: replicas
+    expression: "object.spec.replicas"
+  validations:
+  - expression: "variables.replicas >= int(params.data.minReplicas)"
+    message: "not enough \"replicas\""
+  - expression: "variables.replicas <= 10"
+    messageExpression: "'too many replicas: ' + string(variables.replicas)"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: replicas-binding
+spec:
+  policyName: replicas
+  validationActions: [Warn, Audit]
+  paramRef:
+    name: replicas-params
+    namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: replicas-params
+  namespace: default
+data:
+  minReplicas: "2"
+`
+
+func TestToValidationConfig(t *testing.T) {
+	docs, _, err := helpers.UnmarshalDocuments([]byte(manifests))
+	if err != nil {
+		t.Fatalf("Error unmarshalling manifests: %v", err)
+	}
+	if !ContainsPolicy(docs) {
+		t.Fatalf("Expected manifests to contain a policy")
+	}
+	config, err := ToValidationConfig(docs)
+	if err != nil {
+		t.Fatalf("Error translating policy: %v", err)
+	}
+	conditions := []models.MatchCondition{{Name: "is-deployment", Expression: "object.kind == 'Deployment'"}}
+	expected := models.ValidationConfig{
+		Variables: []models.Variable{{Name: "replicas", Expression: "object.spec.replicas"}},
+		Validations: []models.ValidationRule{
+			{
+				ID:                "replicas/1",
+				Severity:          models.SeverityWarning,
+				MatchConditions:   conditions,
+				Expression:        "variables.replicas >= int(params.data.minReplicas)",
+				MessageExpression: `"not enough \"replicas\""`,
+			},
+			{
+				ID:                "replicas/2",
+				Severity:          models.SeverityWarning,
+				MatchConditions:   conditions,
+				Expression:        "variables.replicas <= 10",
+				MessageExpression: "'too many replicas: ' + string(variables.replicas)",
+			},
+		},
+		Params: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "replicas-params", "namespace": "default"},
+			"data":       map[string]interface{}{"minReplicas": "2"},
+		},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+}
+
+func TestToValidationConfigErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		manifests     string
+		expectedError string
+	}{
+		{
+			name: "missing params",
+			manifests: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: replicas
+spec:
+  paramKind: {apiVersion: v1, kind: ConfigMap}
+  validations:
+  - expression: "object.spec.replicas >= int(params.data.minReplicas)"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: replicas-binding
+spec:
+  policyName: replicas
+  validationActions: [Deny]
+  paramRef: {name: missing}
+`,
+		},
+		{
+			name: "binding without actions",
+			manifests: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata: {name: replicas}
+spec:
+  validations:
+  - expression: "object.spec.replicas > 1"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata: {name: replicas-binding}
+spec:
+  policyName: replicas
+`,
+			expectedError: "Error reading binding 'replicas-binding': validationActions must not be empty",
+		},
+		{
+			name: "params selector",
+			manifests: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata: {name: replicas}
+spec:
+  paramKind: {apiVersion: v1, kind: ConfigMap}
+  validations:
+  - expression: "object.spec.replicas >= int(params.data.minReplicas)"
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata: {name: replicas-binding}
+spec:
+  policyName: replicas
+  validationActions: [Deny]
+  paramRef:
+    selector: {matchLabels: {team: a}}
+`,
+			expectedError: "paramRef.selector is not supported",
+		},
+		{
+			name: "conflicting variables",
+			manifests: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata: {name: a}
+spec:
+  variables: [{name: replicas, expression: "object.spec.replicas"}]
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata: {name: b}
+spec:
+  variables: [{name: replicas, expression: "object.replicas"}]
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, _, err := helpers.UnmarshalDocuments([]byte(tc.manifests))
+			if err != nil {
+				t.Fatalf("Error unmarshalling manifests: %v", err)
+			}
+			if _, err := ToValidationConfig(docs); err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing '%s', got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSeverityOf(t *testing.T) {
+	testCases := []struct {
+		actions       []string
+		expected      string
+		errorExpected bool
+	}{
+		{actions: []string{"Deny"}, expected: models.SeverityError},
+		{actions: []string{"Warn", "Deny"}, expected: models.SeverityError},
+		{actions: []string{"Deny", "Warn"}, expected: models.SeverityError},
+		{actions: []string{"Warn", "Audit"}, expected: models.SeverityWarning},
+		{actions: []string{"Audit"}, expected: models.SeverityInfo},
+		{actions: []string{}, errorExpected: true},
+		{actions: []string{"Block"}, errorExpected: true},
+	}
+	for _, tc := range testCases {
+		actual, err := severityOf(tc.actions)
+		if (err != nil) != tc.errorExpected {
+			t.Errorf("Expected error %v for %v, got %v", tc.errorExpected, tc.actions, err)
+		}
+		if actual != tc.expected {
+			t.Errorf("Expected severity %s for %v, got %s", tc.expected, tc.actions, actual)
+		}
+	}
+}